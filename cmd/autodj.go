@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/AJMerr/gompc/internal/autodj"
+	"github.com/AJMerr/gompc/internal/mpd"
+	"github.com/AJMerr/gompc/internal/smart"
+	"github.com/AJMerr/gompc/internal/stickers"
+)
+
+var autodjSubsystems = []string{"player", "playlist", "options", "database", "sticker"}
+
+func init() {
+	autodjCmd := &cobra.Command{
+		Use:   "autodj",
+		Short: "Keep the queue topped up with songs from the library",
+		Long: "Runs until interrupted, keeping --upcoming songs queued after the\n" +
+			"current one. Songs are picked from the library by --strategy (random,\n" +
+			"or the same artist, genre or decade as the song before), weighted by\n" +
+			"rating with --weighted, skipping songs played within --avoid. When the\n" +
+			"queue has run out, playback resumes. Settings live under [autodj] in\n" +
+			"config.toml; the TUI toggles the same auto-DJ with A.",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := autodjConfig()
+			if err != nil {
+				return err
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return runAutoDJ(ctx, mpdConfig(), autodj.New(cfg))
+		},
+	}
+	viper.SetDefault("autodj.upcoming", 5)
+	viper.SetDefault("autodj.strategy", string(autodj.Random))
+	viper.SetDefault("autodj.weighted", true)
+	viper.SetDefault("autodj.avoid", "24h")
+	autodjCmd.Flags().Int("upcoming", 0, "Songs to keep queued after the current one")
+	autodjCmd.Flags().String("strategy", "", "random, artist, genre or decade")
+	autodjCmd.Flags().Bool("weighted", true, "Prefer higher-rated songs")
+	autodjCmd.Flags().String("avoid", "", "Skip songs played within this long (e.g. 24h, 7d; 0 = don't)")
+	for _, f := range []string{"upcoming", "strategy", "weighted", "avoid"} {
+		_ = viper.BindPFlag("autodj."+f, autodjCmd.Flags().Lookup(f))
+	}
+	rootCmd.AddCommand(autodjCmd)
+}
+
+// [autodj] settings, shared with the TUI.
+func autodjConfig() (autodj.Config, error) {
+	strategy, err := autodj.ParseStrategy(viper.GetString("autodj.strategy"))
+	if err != nil {
+		return autodj.Config{}, err
+	}
+	var avoid time.Duration
+	if s := viper.GetString("autodj.avoid"); s != "" && s != "0" {
+		if avoid, err = smart.ParseSpan(s); err != nil {
+			return autodj.Config{}, fmt.Errorf("autodj.avoid: %w", err)
+		}
+	}
+	return autodj.Config{
+		Upcoming: viper.GetInt("autodj.upcoming"),
+		Strategy: strategy,
+		Weighted: viper.GetBool("autodj.weighted"),
+		Avoid:    avoid,
+	}, nil
+}
+
+func runAutoDJ(ctx context.Context, cfg mpd.Config, dj *autodj.DJ) error {
+	return keepConnected(ctx, "autodj", func(connected func()) error {
+		return autodjConn(ctx, cfg, dj, connected)
+	})
+}
+
+func autodjConn(ctx context.Context, cfg mpd.Config, dj *autodj.DJ, connected func()) error {
+	timeout := opTimeout(cfg)
+	conn, idle, err := dialPair(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	defer idle.Close()
+	connected()
+
+	var lib []mpd.Track
+	var db stickers.DB
+	loadLib, loadStickers := true, dj.Config().NeedsStickers()
+	for {
+		qctx, cancel := context.WithTimeout(ctx, timeout)
+		if loadLib {
+			if lib, err = conn.ListAll(qctx); err != nil {
+				cancel()
+				return err
+			}
+		}
+		if loadStickers {
+			// no sticker database: unweighted, no last-played check
+			db, _ = stickers.Load(qctx, conn)
+		}
+		added, err := dj.TopUp(qctx, conn, lib, db)
+		cancel()
+		for _, t := range added {
+			fmt.Printf("%s queued %s\n", time.Now().Format("15:04:05"), songText(t))
+		}
+		if err != nil {
+			return err
+		}
+
+		changed, err := idle.Idle(ctx, autodjSubsystems)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return err
+		}
+		loadLib = slices.Contains(changed, "database")
+		loadStickers = dj.Config().NeedsStickers() && slices.Contains(changed, "sticker")
+	}
+}
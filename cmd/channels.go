@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+)
+
+// One line of `gompc listen`.
+type messageView struct {
+	Time    string `json:"time"`
+	Channel string `json:"channel"`
+	Message string `json:"message"`
+}
+
+func init() {
+	sendCmd := &cobra.Command{
+		Use:   "send <channel> <message>...",
+		Short: "Send a message to the clients listening on a channel",
+		Long: "Sends the words as one message through MPD to every client subscribed\n" +
+			"to the channel, e.g. a `gompc listen` elsewhere. Fails when nobody is\n" +
+			"listening.",
+		Args:         cobra.MinimumNArgs(2),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			text := strings.Join(args[1:], " ")
+			if strings.ContainsAny(text, "\r\n") {
+				return errors.New("messages are a single line")
+			}
+			return withConn(func(ctx context.Context, conn mpd.Conn) error {
+				return conn.SendMessage(ctx, args[0], text)
+			})
+		},
+	}
+	rootCmd.AddCommand(sendCmd)
+
+	listenCmd := &cobra.Command{
+		Use:   "listen <channel>...",
+		Short: "Print messages sent to channels until interrupted",
+		Long: "Subscribes to the channels and prints each message as it arrives: the\n" +
+			"text alone for one channel, \"channel: text\" for several. Reconnects\n" +
+			"on its own; messages sent while disconnected are lost. --format sees\n" +
+			".Time, .Channel and .Message; --json writes one object per line.",
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tmpl, err := formatFlag(cmd)
+			if err != nil {
+				return err
+			}
+			emit := func(m mpd.Message) error {
+				v := messageView{Time: time.Now().Format(time.RFC3339), Channel: m.Channel, Message: m.Text}
+				switch {
+				case jsonFlag(cmd):
+					b, err := json.Marshal(v)
+					if err != nil {
+						return err
+					}
+					_, err = os.Stdout.Write(append(b, '\n'))
+					return err
+				case tmpl != nil:
+					return execTemplate(os.Stdout, tmpl, v)
+				case len(args) > 1:
+					_, err := fmt.Printf("%s: %s\n", v.Channel, v.Message)
+					return err
+				}
+				_, err := fmt.Println(v.Message)
+				return err
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return keepConnected(ctx, "listen", func(connected func()) error {
+				return listen(ctx, mpdConfig(), args, connected, emit)
+			})
+		},
+	}
+	addFormatFlags(listenCmd)
+	rootCmd.AddCommand(listenCmd)
+
+	channelsCmd := &cobra.Command{
+		Use:          "channels",
+		Short:        "List the channels someone is listening on",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withConn(func(ctx context.Context, conn mpd.Conn) error {
+				names, err := conn.Channels(ctx)
+				if err != nil {
+					return err
+				}
+				if jsonFlag(cmd) {
+					return writeJSON(names)
+				}
+				for _, name := range names {
+					fmt.Println(name)
+				}
+				return nil
+			})
+		},
+	}
+	channelsCmd.Flags().Bool("json", false, "Output JSON")
+	rootCmd.AddCommand(channelsCmd)
+}
+
+// Subscribes one connection to channels and hands each message to emit
+// after every message event, until ctx is done or the connection fails.
+func listen(ctx context.Context, cfg mpd.Config, channels []string, connected func(), emit func(mpd.Message) error) error {
+	dctx, cancel := context.WithTimeout(ctx, max(cfg.Timeout, time.Second))
+	conn, err := mpd.NewClient().Connect(dctx, cfg)
+	cancel()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	qctx, cancel := context.WithTimeout(ctx, opTimeout(cfg))
+	for _, ch := range channels {
+		if err = conn.Subscribe(qctx, ch); err != nil {
+			err = fmt.Errorf("subscribe %s: %w", ch, err)
+			break
+		}
+	}
+	cancel()
+	if err != nil {
+		return err
+	}
+	connected()
+
+	for {
+		qctx, cancel := context.WithTimeout(ctx, opTimeout(cfg))
+		msgs, err := conn.ReadMessages(qctx)
+		cancel()
+		if err != nil {
+			return err
+		}
+		for _, m := range msgs {
+			if err := emit(m); err != nil {
+				return outputError{err}
+			}
+		}
+		if _, err := conn.Idle(ctx, []string{"message"}); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return err
+		}
+	}
+}
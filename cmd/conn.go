@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+)
+
+// Connection settings from flags, env and config.
+func mpdConfig() mpd.Config {
+	return mpd.Config{
+		Host:      viper.GetString("mpd.host"),
+		Port:      viper.GetInt("mpd.port"),
+		Timeout:   time.Duration(viper.GetInt("mpd.timeout_ms")) * time.Millisecond,
+		Partition: viper.GetString("mpd.partition"),
+	}
+}
+
+// The --timeout value, or 2s when unset.
+func opTimeout(cfg mpd.Config) time.Duration {
+	if cfg.Timeout <= 0 {
+		return 2 * time.Second
+	}
+	return cfg.Timeout
+}
+
+// Dials MPD, runs fn and hangs up. The timeout covers the whole exchange.
+func withConn(fn func(ctx context.Context, conn mpd.Conn) error) error {
+	cfg := mpdConfig()
+	ctx, cancel := context.WithTimeout(context.Background(), opTimeout(cfg))
+	defer cancel()
+
+	conn, err := mpd.NewClient().Connect(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return fn(ctx, conn)
+}
+
+// Runs a long-lived session until ctx is done, reconnecting with backoff.
+// session calls connected once it is up; each outage is logged once to
+// stderr. An outputError ends it instead.
+func keepConnected(ctx context.Context, name string, session func(connected func()) error) error {
+	return reconnect(ctx, func(err error) error {
+		fmt.Fprintf(os.Stderr, "%s: disconnected: %v\n", name, err)
+		return nil
+	}, session)
+}
+
+// keepConnected with the outage report up to the caller: down runs once
+// per outage, and an error from it ends the loop.
+func reconnect(ctx context.Context, down func(err error) error, session func(connected func()) error) error {
+	backoff := time.Second
+	isDown := false
+	for {
+		err := session(func() { isDown, backoff = false, time.Second })
+		if ctx.Err() != nil {
+			return nil
+		}
+		var out outputError
+		if errors.As(err, &out) {
+			return out.err
+		}
+		if !isDown {
+			isDown = true
+			if err := down(err); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, 30*time.Second)
+	}
+}
+
+// Wraps output failures (stdout closed, e.g. the bar restarted) so the
+// reconnect loop ends instead of retrying them.
+type outputError struct{ err error }
+
+func (e outputError) Error() string { return e.err.Error() }
+
+// A command connection plus one to idle on.
+func dialPair(ctx context.Context, cfg mpd.Config) (conn, idle mpd.Conn, err error) {
+	dctx, cancel := context.WithTimeout(ctx, max(cfg.Timeout, 5*time.Second))
+	defer cancel()
+	if conn, err = mpd.NewClient().Connect(dctx, cfg); err != nil {
+		return nil, nil, err
+	}
+	if idle, err = mpd.NewClient().Connect(dctx, cfg); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return conn, idle, nil
+}
+
+// Passes a status snapshot to fn on connecting and after every player
+// event, until ctx is done or the connection fails. fn may use conn.
+func followPlayer(ctx context.Context, cfg mpd.Config, connected func(), fn func(conn mpd.Conn, np mpd.NowPlaying, at time.Time) error) error {
+	conn, idle, err := dialPair(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	defer idle.Close()
+	connected()
+
+	for {
+		qctx, cancel := context.WithTimeout(ctx, opTimeout(cfg))
+		np, err := conn.Status(qctx)
+		cancel()
+		if err != nil {
+			return err
+		}
+		if err := fn(conn, np, time.Now()); err != nil {
+			return err
+		}
+		if _, err := idle.Idle(ctx, []string{"player"}); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return err
+		}
+	}
+}
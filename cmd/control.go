@@ -0,0 +1,408 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+)
+
+// One-shot commands for scripts (an mpc replacement). Errors, including
+// ACKs from MPD, exit non-zero via Execute.
+
+func init() {
+	simple := []struct {
+		use, short string
+		run        func(ctx context.Context, conn mpd.Conn) error
+	}{
+		{"pause", "Pause playback", func(ctx context.Context, c mpd.Conn) error { return c.Pause(ctx, true) }},
+		{"toggle", "Toggle between play and pause", func(ctx context.Context, c mpd.Conn) error { return c.TogglePause(ctx) }},
+		{"next", "Play the next song in the queue", func(ctx context.Context, c mpd.Conn) error { return c.Next(ctx) }},
+		{"prev", "Play the previous song in the queue", func(ctx context.Context, c mpd.Conn) error { return c.Prev(ctx) }},
+		{"stop", "Stop playback", func(ctx context.Context, c mpd.Conn) error { return c.Stop(ctx) }},
+		{"clear", "Clear the queue", func(ctx context.Context, c mpd.Conn) error { return c.QueueClear(ctx) }},
+	}
+	for _, sc := range simple {
+		run := sc.run
+		rootCmd.AddCommand(&cobra.Command{
+			Use:          sc.use,
+			Short:        sc.short,
+			Args:         cobra.NoArgs,
+			SilenceUsage: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return withConn(run)
+			},
+		})
+	}
+
+	rootCmd.AddCommand(
+		&cobra.Command{
+			Use:          "play [position]",
+			Short:        "Start playback, optionally at a queue position (1-based)",
+			Args:         cobra.MaximumNArgs(1),
+			SilenceUsage: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				pos := -1
+				if len(args) == 1 {
+					n, err := strconv.Atoi(args[0])
+					if err != nil || n < 1 {
+						return fmt.Errorf("bad position %q", args[0])
+					}
+					pos = n - 1
+				}
+				return withConn(func(ctx context.Context, conn mpd.Conn) error {
+					if pos < 0 {
+						return conn.Resume(ctx)
+					}
+					return conn.PlayPos(ctx, pos)
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "seek <[+-][[HH:]MM:]SS | [+-]N%>",
+			Short: "Seek within the current song",
+			Long: "Seek to an absolute position (90, 1:30), relative to the current\n" +
+				"one (+10, -0:30) or to a percentage of the song (50%).",
+			Args:         cobra.ExactArgs(1),
+			SilenceUsage: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return withConn(func(ctx context.Context, conn mpd.Conn) error {
+					st, err := conn.PlayerStatus(ctx)
+					if err != nil {
+						return err
+					}
+					if st.State == "stop" || st.Song < 0 {
+						return fmt.Errorf("not playing")
+					}
+					pos, err := seekTarget(args[0], st.Elapsed, st.Duration)
+					if err != nil {
+						return err
+					}
+					return conn.SeekCur(ctx, pos)
+				})
+			},
+		},
+		&cobra.Command{
+			Use:          "volume [[+-]N]",
+			Short:        "Show or set the volume (0-100)",
+			Args:         cobra.MaximumNArgs(1),
+			SilenceUsage: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return withConn(func(ctx context.Context, conn mpd.Conn) error {
+					st, err := conn.PlayerStatus(ctx)
+					if err != nil {
+						return err
+					}
+					if len(args) == 0 {
+						fmt.Println(volumeText(st.Volume))
+						return nil
+					}
+					if st.Volume < 0 {
+						return fmt.Errorf("volume: no mixer")
+					}
+					arg := args[0]
+					n, err := strconv.Atoi(strings.TrimPrefix(arg, "+"))
+					if err != nil {
+						return fmt.Errorf("bad volume %q", arg)
+					}
+					if arg[0] == '+' || arg[0] == '-' {
+						n += st.Volume
+					}
+					return conn.SetVolume(ctx, n)
+				})
+			},
+		},
+		statusCmd("status", "Show the current song and player state",
+			func(st mpd.PlayerStatus, cur mpd.Track) { fmt.Print(statusText(st, cur)) }),
+		statusCmd("current", "Show the current song (prints nothing when stopped)",
+			func(st mpd.PlayerStatus, cur mpd.Track) {
+				if cur.URI != "" {
+					fmt.Println(songText(cur))
+				}
+			}),
+		queueCmd(),
+		&cobra.Command{
+			Use:   "add [uri...]",
+			Short: "Append songs or directories to the queue",
+			Long: "Append songs or directories to the queue. With no arguments, URIs are\n" +
+				"read from stdin one per line, e.g. `gompc search artist x | gompc add`.",
+			SilenceUsage: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				uris := args
+				if len(uris) == 0 {
+					sc := bufio.NewScanner(os.Stdin)
+					for sc.Scan() {
+						if u := strings.TrimSpace(sc.Text()); u != "" {
+							uris = append(uris, u)
+						}
+					}
+					if err := sc.Err(); err != nil {
+						return err
+					}
+				}
+				return withConn(func(ctx context.Context, conn mpd.Conn) error {
+					for _, u := range uris {
+						if err := conn.QueueAdd(ctx, u); err != nil {
+							return err
+						}
+					}
+					return nil
+				})
+			},
+		},
+		&cobra.Command{
+			Use:          "load <playlist>",
+			Short:        "Append a stored playlist to the queue",
+			Args:         cobra.ExactArgs(1),
+			SilenceUsage: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return withConn(func(ctx context.Context, conn mpd.Conn) error {
+					return conn.PlaylistLoad(ctx, args[0])
+				})
+			},
+		},
+		&cobra.Command{
+			Use:          "save <playlist>",
+			Short:        "Save the queue as a stored playlist",
+			Args:         cobra.ExactArgs(1),
+			SilenceUsage: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return withConn(func(ctx context.Context, conn mpd.Conn) error {
+					return conn.PlaylistSave(ctx, args[0])
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "search [tag] <query>",
+			Short: "Search the database and print matching URIs",
+			Long: "Case-insensitive substring search on a tag (artist, album, title,\n" +
+				"genre, file, ...) or on any tag when only a query is given.",
+			Args:         cobra.RangeArgs(1, 2),
+			SilenceUsage: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				tag, query := "any", args[0]
+				if len(args) == 2 {
+					tag, query = args[0], args[1]
+				}
+				return withConn(func(ctx context.Context, conn mpd.Conn) error {
+					ts, err := conn.Search(ctx, tag, query)
+					if err != nil {
+						return err
+					}
+					for _, t := range ts {
+						fmt.Println(t.URI)
+					}
+					return nil
+				})
+			},
+		},
+		updateCmd(),
+	)
+}
+
+// status and current share data and --format/--json; only the default
+// text differs.
+func statusCmd(use, short string, text func(mpd.PlayerStatus, mpd.Track)) *cobra.Command {
+	c := &cobra.Command{
+		Use:          use,
+		Short:        short,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tmpl, err := formatFlag(cmd)
+			if err != nil {
+				return err
+			}
+			return withConn(func(ctx context.Context, conn mpd.Conn) error {
+				st, err := conn.PlayerStatus(ctx)
+				if err != nil {
+					return err
+				}
+				cur, err := conn.CurrentSong(ctx)
+				if err != nil {
+					return err
+				}
+				switch {
+				case jsonFlag(cmd):
+					return writeJSON(newStatusView(st, cur))
+				case tmpl != nil:
+					return execTemplate(os.Stdout, tmpl, newStatusView(st, cur))
+				}
+				text(st, cur)
+				return nil
+			})
+		},
+	}
+	addFormatFlags(c)
+	return c
+}
+
+func queueCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:          "queue",
+		Short:        "List the queue; the current song is marked with >",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tmpl, err := formatFlag(cmd)
+			if err != nil {
+				return err
+			}
+			return withConn(func(ctx context.Context, conn mpd.Conn) error {
+				st, err := conn.PlayerStatus(ctx)
+				if err != nil {
+					return err
+				}
+				q, err := conn.QueueList(ctx)
+				if err != nil {
+					return err
+				}
+				if jsonFlag(cmd) {
+					out := make([]trackView, len(q))
+					for i, t := range q {
+						out[i] = newTrackView(t)
+					}
+					return writeJSON(out)
+				}
+				for _, t := range q {
+					if tmpl != nil {
+						if err := execTemplate(os.Stdout, tmpl, newTrackView(t)); err != nil {
+							return err
+						}
+						continue
+					}
+					mark := " "
+					if t.Pos == st.Song {
+						mark = ">"
+					}
+					fmt.Printf("%s%3d) %s\n", mark, t.Pos+1, songText(t))
+				}
+				return nil
+			})
+		},
+	}
+	addFormatFlags(c)
+	return c
+}
+
+// "Artist - Title", or the file name for untagged songs.
+func songText(t mpd.Track) string {
+	if t.Title == "" {
+		return path.Base(t.URI)
+	}
+	if t.Artist == "" {
+		return t.Title
+	}
+	return t.Artist + " - " + t.Title
+}
+
+func statusText(st mpd.PlayerStatus, cur mpd.Track) string {
+	var b strings.Builder
+	if st.State != "stop" && cur.URI != "" {
+		state := map[string]string{"play": "playing", "pause": "paused"}[st.State]
+		pct := 0
+		if st.Duration > 0 {
+			pct = int(100 * st.Elapsed / st.Duration)
+		}
+		fmt.Fprintf(&b, "%s\n[%s] #%d/%d   %s/%s (%d%%)\n", songText(cur), state,
+			st.Song+1, st.QueueLength, clock(st.Elapsed), clock(st.Duration), pct)
+	}
+	if st.UpdatingDB > 0 {
+		fmt.Fprintf(&b, "updating: job %d\n", st.UpdatingDB)
+	}
+	fmt.Fprintf(&b, "%s   repeat: %s   random: %s   single: %s   consume: %s\n",
+		volumeText(st.Volume), onOff(st.Repeat), onOff(st.Random), flagText(st.Single), flagText(st.Consume))
+	if st.Error != "" {
+		fmt.Fprintf(&b, "error: %s\n", st.Error)
+	}
+	return b.String()
+}
+
+func volumeText(v int) string {
+	if v < 0 {
+		return "volume: n/a"
+	}
+	return fmt.Sprintf("volume: %d%%", v)
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// single/consume: "0", "1" or "oneshot"
+func flagText(s string) string {
+	switch s {
+	case "1":
+		return "on"
+	case "", "0":
+		return "off"
+	}
+	return s
+}
+
+// m:ss, or h:mm:ss past an hour
+func clock(d time.Duration) string {
+	s := int(d.Round(time.Second) / time.Second)
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
+
+// Resolves a seek argument against the current position and length.
+func seekTarget(arg string, elapsed, total time.Duration) (time.Duration, error) {
+	rel := 0
+	s := arg
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		rel = 1
+		if s[0] == '-' {
+			rel = -1
+		}
+		s = s[1:]
+	}
+
+	var d time.Duration
+	if pct, ok := strings.CutSuffix(s, "%"); ok {
+		f, err := strconv.ParseFloat(pct, 64)
+		if err != nil || f < 0 || f > 100 {
+			return 0, fmt.Errorf("bad seek position %q", arg)
+		}
+		d = time.Duration(f / 100 * float64(total))
+	} else {
+		secs := 0.0
+		parts := strings.Split(s, ":")
+		if len(parts) > 3 {
+			return 0, fmt.Errorf("bad seek position %q", arg)
+		}
+		for _, p := range parts {
+			f, err := strconv.ParseFloat(p, 64)
+			if err != nil || f < 0 {
+				return 0, fmt.Errorf("bad seek position %q", arg)
+			}
+			secs = secs*60 + f
+		}
+		d = time.Duration(secs * float64(time.Second))
+	}
+
+	switch rel {
+	case 1:
+		d = elapsed + d
+	case -1:
+		d = elapsed - d
+	}
+	if total > 0 && d > total {
+		d = total
+	}
+	return max(0, d), nil
+}
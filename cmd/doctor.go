@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
+	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/AJMerr/gompc/internal/doctor"
+	"github.com/AJMerr/gompc/internal/mpd"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -35,6 +39,23 @@ func init() {
 				doctor.RenderJSON(rep)
 			} else {
 				doctor.RenderHuman(cfg, viper.ConfigFileUsed(), rep)
+				if outputsWarned(rep) && interactive() {
+					enabled, err := offerEnableOutput()
+					if err != nil {
+						fmt.Fprintln(os.Stderr, "outputs:", err)
+					}
+					if enabled {
+						// check again, so the exit code is for the fixed setup
+						rctx, rcancel := context.WithTimeout(context.Background(), time.Duration(timeoutMS)*time.Millisecond)
+						rep = doctor.Run(rctx, cfg, deep)
+						rcancel()
+						for _, c := range rep.Checks {
+							if c.Name == "output" {
+								doctor.RenderCheck(c)
+							}
+						}
+					}
+				}
 			}
 			if rep.ExitCode != 0 {
 				os.Exit(rep.ExitCode)
@@ -48,3 +69,58 @@ func init() {
 
 	rootCmd.AddCommand(doctorCmd)
 }
+
+func outputsWarned(rep doctor.Report) bool {
+	for _, c := range rep.Checks {
+		if c.Name == "output" && c.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+// Both ends are a terminal, so there's someone to ask.
+func interactive() bool {
+	for _, f := range []*os.File{os.Stdin, os.Stdout} {
+		fi, err := f.Stat()
+		if err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Lists the outputs and asks which one to enable; reports whether one was.
+func offerEnableOutput() (bool, error) {
+	var outs []mpd.Output
+	err := withConn(func(ctx context.Context, conn mpd.Conn) error {
+		var err error
+		outs, err = conn.Outputs(ctx)
+		return err
+	})
+	if err != nil || len(outs) == 0 {
+		return false, err
+	}
+	fmt.Println("\nNo output is enabled, so nothing will be heard. Outputs:")
+	for _, o := range outs {
+		fmt.Println("  " + outputText(o))
+	}
+	fmt.Print("Enable which output? [id or name, empty to skip] ")
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return false, nil
+	}
+	o, err := findOutput(outs, line)
+	if err != nil {
+		return false, err
+	}
+	err = withConn(func(ctx context.Context, conn mpd.Conn) error {
+		return conn.EnableOutput(ctx, o.ID)
+	})
+	if err != nil {
+		return false, err
+	}
+	fmt.Printf("Enabled %d %s.\n", o.ID, o.Name)
+	return true, nil
+}
@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+)
+
+// Data behind --format and --json for status-style commands. Templates
+// see Go durations (use the duration helper); JSON gets milliseconds.
+type statusView struct {
+	State       string        `json:"state"`
+	Volume      int           `json:"volume"` // -1 = no mixer
+	Repeat      bool          `json:"repeat"`
+	Random      bool          `json:"random"`
+	Single      string        `json:"single"`
+	Consume     string        `json:"consume"`
+	Position    int           `json:"position"` // 1-based, 0 = none
+	QueueLength int           `json:"queue_length"`
+	Elapsed     time.Duration `json:"-"`
+	Duration    time.Duration `json:"-"`
+	ElapsedMS   int64         `json:"elapsed_ms"`
+	DurationMS  int64         `json:"duration_ms"`
+	Bitrate     int           `json:"bitrate"`
+	Audio       string        `json:"audio"`
+	UpdatingDB  int           `json:"updating_db"`
+	Error       string        `json:"error,omitempty"`
+	Current     trackView     `json:"current,omitzero"`
+}
+
+type trackView struct {
+	URI        string        `json:"uri"`
+	Title      string        `json:"title"`
+	Artist     string        `json:"artist"`
+	Album      string        `json:"album"`
+	Genre      string        `json:"genre,omitempty"`
+	Composer   string        `json:"composer,omitempty"`
+	Work       string        `json:"work,omitempty"`
+	Year       int           `json:"year,omitempty"`
+	Track      int           `json:"track,omitempty"`
+	Disc       int           `json:"disc,omitempty"`
+	Position   int           `json:"position,omitempty"` // 1-based queue position
+	ID         int           `json:"id,omitempty"`
+	Duration   time.Duration `json:"-"`
+	DurationMS int64         `json:"duration_ms"`
+}
+
+func newStatusView(st mpd.PlayerStatus, cur mpd.Track) statusView {
+	v := statusView{
+		State:       st.State,
+		Volume:      st.Volume,
+		Repeat:      st.Repeat,
+		Random:      st.Random,
+		Single:      st.Single,
+		Consume:     st.Consume,
+		Position:    st.Song + 1,
+		QueueLength: st.QueueLength,
+		Elapsed:     st.Elapsed,
+		Duration:    st.Duration,
+		ElapsedMS:   ms(st.Elapsed),
+		DurationMS:  ms(st.Duration),
+		Bitrate:     st.Bitrate,
+		Audio:       st.Audio,
+		UpdatingDB:  st.UpdatingDB,
+		Error:       st.Error,
+	}
+	if cur.URI != "" {
+		v.Current = newTrackView(cur)
+	}
+	return v
+}
+
+func newTrackView(t mpd.Track) trackView {
+	v := trackView{
+		URI:        t.URI,
+		Title:      t.Title,
+		Artist:     t.Artist,
+		Album:      t.Album,
+		Genre:      t.Genre,
+		Composer:   t.Composer,
+		Work:       t.Work,
+		Year:       t.Year,
+		Track:      t.TrackNo,
+		Disc:       t.DiscNo,
+		ID:         t.ID,
+		Duration:   t.Duration,
+		DurationMS: ms(t.Duration),
+	}
+	if t.ID > 0 {
+		v.Position = t.Pos + 1
+	}
+	return v
+}
+
+var templateFuncs = template.FuncMap{
+	// m:ss (h:mm:ss past an hour)
+	"duration": clock,
+	// first argument when the value is empty: {{default "-" .Current.Album}}
+	"default": func(def, v any) any {
+		if v == nil || reflect.ValueOf(v).IsZero() {
+			return def
+		}
+		return v
+	},
+	// at most n runes, with an ellipsis when cut
+	"truncate": func(n int, s string) string {
+		r := []rune(s)
+		if n <= 0 || len(r) <= n {
+			return s
+		}
+		if n == 1 {
+			return "…"
+		}
+		return string(r[:n-1]) + "…"
+	},
+	// whole percent of part/total: {{percent .Elapsed .Duration}}
+	"percent": func(part, total any) int {
+		p, t := toFloat(part), toFloat(total)
+		if t <= 0 {
+			return 0
+		}
+		return int(100 * p / t)
+	},
+}
+
+func toFloat(v any) float64 {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	}
+	return 0
+}
+
+func addFormatFlags(c *cobra.Command) {
+	c.Flags().String("format", "", "Go text/template for the output (see `gompc help format`)")
+	c.Flags().Bool("json", false, "Output JSON")
+	c.MarkFlagsMutuallyExclusive("format", "json")
+}
+
+// Parsed --format template, or nil when unset.
+func formatFlag(c *cobra.Command) (*template.Template, error) {
+	f, _ := c.Flags().GetString("format")
+	if f == "" {
+		return nil, nil
+	}
+	t, err := template.New("format").Funcs(templateFuncs).Option("missingkey=error").Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("--format: %w", err)
+	}
+	return t, nil
+}
+
+func jsonFlag(c *cobra.Command) bool {
+	j, _ := c.Flags().GetBool("json")
+	return j
+}
+
+// Runs t over data and ends the output with a newline.
+func execTemplate(w io.Writer, t *template.Template, data any) error {
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return fmt.Errorf("--format: %w", err)
+	}
+	out := b.String()
+	if !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	_, err := io.WriteString(w, out)
+	return err
+}
+
+// Indented like doctor --json.
+func writeJSON(v any) error {
+	b, err := json.MarshalIndent(v, "", " ")
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(append(b, '\n'))
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "format",
+		Short: "Fields and helpers for --format templates",
+		Long: `status and current take --format, a Go text/template run over:
+
+  .State .Volume .Repeat .Random .Single .Consume
+  .Position .QueueLength .Elapsed .Duration .Bitrate .Audio .UpdatingDB .Error
+  .Current.URI .Current.Title .Current.Artist .Current.Album .Current.Genre
+  .Current.Composer .Current.Work .Current.Year .Current.Track .Current.Disc .Current.Duration
+
+queue runs the template once per song over the .Current fields plus
+.Position and .ID.
+
+Helpers:
+  duration .Elapsed          1:23 (h:mm:ss past an hour)
+  default "-" .Current.Album  the first argument when the value is empty
+  truncate 30 .Current.Title  cut to 30 characters with …
+  percent .Elapsed .Duration  whole percent
+
+Example:
+  gompc status --format '{{.Current.Artist}} - {{truncate 30 .Current.Title}} [{{duration .Elapsed}}]'`,
+	})
+}
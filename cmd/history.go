@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/AJMerr/gompc/internal/history"
+	"github.com/AJMerr/gompc/internal/mpd"
+	"github.com/AJMerr/gompc/internal/plays"
+	"github.com/AJMerr/gompc/internal/smart"
+)
+
+func init() {
+	var limit int
+	historyCmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show recently played songs",
+		Long: "Show the local listening history, newest first. With history.enabled\n" +
+			"set, the TUI records every finished or skipped song while it runs;\n" +
+			"`gompc history record` does the same without it. Run one recorder, not\n" +
+			"both, or every play is logged twice. The log is JSON lines at\n" +
+			"history.file (default " + filepath.Join(dataDir(), "history.jsonl") + ").",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := nonNegative(cmd, "limit"); err != nil {
+				return err
+			}
+			entries, err := history.Read(historyPath())
+			if err != nil {
+				return err
+			}
+			recent := entries[max(0, len(entries)-limit):]
+			if jsonFlag(cmd) {
+				return writeJSON(recent)
+			}
+			for i := len(recent) - 1; i >= 0; i-- {
+				fmt.Println(historyText(recent[i]))
+			}
+			return nil
+		},
+	}
+	historyCmd.Flags().IntVarP(&limit, "limit", "n", 20, "Number of songs")
+	historyCmd.Flags().Bool("json", false, "Output JSON")
+
+	historyCmd.AddCommand(&cobra.Command{
+		Use:          "record",
+		Short:        "Record plays to the history until interrupted",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			cfg, path := mpdConfig(), historyPath()
+			var tr plays.Tracker
+			return keepConnected(ctx, "history", func(connected func()) error {
+				return followPlayer(ctx, cfg, connected, func(_ mpd.Conn, np mpd.NowPlaying, at time.Time) error {
+					p, ok := tr.Observe(np, at)
+					if !ok {
+						return nil
+					}
+					if e, ok := history.FromPlay(p); ok {
+						return history.Append(path, e)
+					}
+					return nil
+				})
+			})
+		},
+	})
+
+	var since string
+	var topN, days int
+	statsCmd := &cobra.Command{
+		Use:          "stats",
+		Short:        "Summarise the listening history",
+		Long:         "Top artists, albums and tracks, listening time per day and week, and\nskip rates, from the history `gompc history` shows.",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := nonNegative(cmd, "top", "days"); err != nil {
+				return err
+			}
+			var from time.Time
+			if since != "" {
+				d, err := smart.ParseSpan(since)
+				if err != nil {
+					return fmt.Errorf("--since: %w", err)
+				}
+				from = time.Now().Add(-d)
+			}
+			entries, err := history.Read(historyPath())
+			if err != nil {
+				return err
+			}
+			r := history.Summarize(entries, from, topN, time.Local)
+			r.Days = r.Days[:min(days, len(r.Days))]
+			if jsonFlag(cmd) {
+				return writeJSON(r)
+			}
+			printStats(r)
+			return nil
+		},
+	}
+	statsCmd.Flags().StringVar(&since, "since", "", "Only the last span, e.g. 30d, 2w")
+	statsCmd.Flags().IntVar(&topN, "top", 10, "Length of the top lists")
+	statsCmd.Flags().IntVar(&days, "days", 14, "Days to show")
+	statsCmd.Flags().Bool("json", false, "Output JSON")
+
+	viper.SetDefault("history.enabled", false)
+	rootCmd.AddCommand(historyCmd, statsCmd)
+}
+
+// history.file from config, or the XDG default.
+func historyPath() string {
+	if p := viper.GetString("history.file"); p != "" {
+		return expandHome(p)
+	}
+	return filepath.Join(dataDir(), "history.jsonl")
+}
+
+// Rejects negative values of the named int flags, showing the usage.
+func nonNegative(cmd *cobra.Command, flags ...string) error {
+	for _, name := range flags {
+		if n, _ := cmd.Flags().GetInt(name); n < 0 {
+			cmd.SilenceUsage = false
+			return fmt.Errorf("--%s can't be negative", name)
+		}
+	}
+	return nil
+}
+
+// "2026-10-18 21:04  Artist - Title" with a skip marker
+func historyText(e history.Entry) string {
+	mark := " "
+	if e.Skipped {
+		mark = "↷"
+	}
+	t := mpd.Track{URI: e.URI, Title: e.Title, Artist: e.Artist}
+	return fmt.Sprintf("%s %s %s", e.Ended.Local().Format("2006-01-02 15:04"), mark, songText(t))
+}
+
+func printStats(r history.Report) {
+	if r.Plays+r.Skips == 0 {
+		fmt.Println("No history yet.")
+		return
+	}
+	fmt.Printf("%s – %s: %d plays, %d skips (%.0f%%), %s listened\n",
+		r.From.Local().Format("2006-01-02"), r.To.Local().Format("2006-01-02"),
+		r.Plays, r.Skips, 100*r.SkipRate, hoursMins(r.Listened))
+
+	section := func(title string, cs []history.Count) {
+		if len(cs) == 0 {
+			return
+		}
+		fmt.Printf("\n%s\n", title)
+		for i, c := range cs {
+			name := c.Name
+			if c.Artist != "" {
+				name += " — " + c.Artist
+			}
+			fmt.Printf("%3d. %-40s %4d plays %7s %4.0f%% skipped\n",
+				i+1, fitRunes(name, 40), c.Plays, hoursMins(c.Listened), 100*c.SkipRate)
+		}
+	}
+	section("Top artists", r.TopArtists)
+	section("Top albums", r.TopAlbums)
+	section("Top tracks", r.TopTracks)
+	section("Most skipped artists", r.MostSkips)
+
+	buckets := func(title string, bs []history.Bucket) {
+		if len(bs) == 0 {
+			return
+		}
+		var most time.Duration
+		for _, b := range bs {
+			most = max(most, b.Listened)
+		}
+		fmt.Printf("\n%s\n", title)
+		for _, b := range bs {
+			bar := strings.Repeat("█", int(20*b.Listened/max(most, 1)))
+			fmt.Printf("  %-10s %7s %4d plays %s\n", b.Label, hoursMins(b.Listened), b.Plays, bar)
+		}
+	}
+	buckets("Per day", r.Days)
+	buckets("Per week", r.Weeks)
+}
+
+// "8h12m" or "42m"
+func hoursMins(d time.Duration) string {
+	m := int(d.Round(time.Minute) / time.Minute)
+	if m >= 60 {
+		return fmt.Sprintf("%dh%02dm", m/60, m%60)
+	}
+	return fmt.Sprintf("%dm", m)
+}
+
+func fitRunes(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+)
+
+type mountView struct {
+	Point   string `json:"point"`
+	Storage string `json:"storage"`
+}
+
+type neighborView struct {
+	URI  string `json:"uri"`
+	Name string `json:"name"`
+}
+
+func init() {
+	mountsCmd := &cobra.Command{
+		Use:   "mounts",
+		Short: "List storages mounted into the music directory",
+		Long: "Lists MPD's mounts: \"/\" is the music directory itself, the rest are\n" +
+			"NFS, SMB, UPnP, ... storages mounted below it. Needs a database plugin\n" +
+			"that supports mounting (simple, with cache_directory).",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withConn(func(ctx context.Context, conn mpd.Conn) error {
+				mounts, err := conn.ListMounts(ctx)
+				if err != nil {
+					return err
+				}
+				if jsonFlag(cmd) {
+					views := make([]mountView, len(mounts))
+					for i, m := range mounts {
+						views[i] = mountView(m)
+					}
+					return writeJSON(views)
+				}
+				for _, m := range mounts {
+					fmt.Printf("%s\t%s\n", mountPoint(m.Point), m.Storage)
+				}
+				return nil
+			})
+		},
+	}
+	mountsCmd.Flags().Bool("json", false, "Output JSON")
+
+	mountsCmd.AddCommand(&cobra.Command{
+		Use:   "add <path> <uri>",
+		Short: "Mount a storage at a path in the music directory",
+		Long: "Mounts the storage, e.g.\n" +
+			"  gompc mounts add nas nfs://nas.local/srv/music\n" +
+			"then index it with `gompc update nas`.",
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withConn(func(ctx context.Context, conn mpd.Conn) error {
+				return conn.Mount(ctx, args[0], args[1])
+			})
+		},
+	})
+
+	mountsCmd.AddCommand(&cobra.Command{
+		Use:          "remove <path>...",
+		Short:        "Unmount storages",
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withConn(func(ctx context.Context, conn mpd.Conn) error {
+				for _, p := range args {
+					if err := conn.Unmount(ctx, p); err != nil {
+						return fmt.Errorf("%s: %w", p, err)
+					}
+				}
+				return nil
+			})
+		},
+	})
+
+	neighborsCmd := &cobra.Command{
+		Use:          "neighbors",
+		Short:        "List storages found on the network, ready to mount",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withConn(func(ctx context.Context, conn mpd.Conn) error {
+				ns, err := conn.ListNeighbors(ctx)
+				if err != nil {
+					return err
+				}
+				if jsonFlag(cmd) {
+					views := make([]neighborView, len(ns))
+					for i, n := range ns {
+						views[i] = neighborView(n)
+					}
+					return writeJSON(views)
+				}
+				for _, n := range ns {
+					fmt.Printf("%s\t%s\n", n.URI, n.Name)
+				}
+				return nil
+			})
+		},
+	}
+	neighborsCmd.Flags().Bool("json", false, "Output JSON")
+	mountsCmd.AddCommand(neighborsCmd)
+
+	rootCmd.AddCommand(mountsCmd)
+}
+
+// "/" for the root mount, which MPD lists as "".
+func mountPoint(p string) string {
+	if p == "" {
+		return "/"
+	}
+	return p
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/AJMerr/gompc/internal/art"
+	"github.com/AJMerr/gompc/internal/mpd"
+	"github.com/AJMerr/gompc/internal/mpris"
+)
+
+var mprisSubsystems = []string{"player", "mixer", "options", "playlist"}
+
+func init() {
+	mprisCmd := &cobra.Command{
+		Use:   "mpris",
+		Short: "Control MPD through MPRIS on D-Bus (media keys, desktop widgets)",
+		Long: "Runs until interrupted, owning org.mpris.MediaPlayer2.<name> on the\n" +
+			"session bus so media keys, GNOME and KDE widgets, KDE Connect and\n" +
+			"playerctl can drive MPD. Covers are fetched from MPD and cached for\n" +
+			"mpris:artUrl; set mpd.music_dir for file:// song URLs. --bus takes\n" +
+			"another bus address, e.g. a private dbus-daemon for testing.",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bus, err := connectBus(viper.GetString("mpris.bus"))
+			if err != nil {
+				return fmt.Errorf("d-bus: %w", err)
+			}
+			defer bus.Close()
+
+			cfg := mpris.Config{
+				Name:     viper.GetString("mpris.name"),
+				MusicDir: expandHome(viper.GetString("mpd.music_dir")),
+				Art:      artCache(),
+			}
+			b, err := mpris.New(bus, cfg)
+			if err != nil {
+				return err
+			}
+			fmt.Println("serving", b.BusName())
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return runMPRIS(ctx, mpdConfig(), b)
+		},
+	}
+	viper.SetDefault("mpris.name", "gompc")
+	mprisCmd.Flags().String("name", "", "Bus name suffix: org.mpris.MediaPlayer2.<name> (default gompc)")
+	mprisCmd.Flags().String("bus", "", "D-Bus address (default: the session bus)")
+	_ = viper.BindPFlag("mpris.name", mprisCmd.Flags().Lookup("name"))
+	_ = viper.BindPFlag("mpris.bus", mprisCmd.Flags().Lookup("bus"))
+	rootCmd.AddCommand(mprisCmd)
+}
+
+// Covers shared by mpris and notify, under the user cache directory.
+func artCache() art.Cache {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return art.Cache{}
+	}
+	return art.Cache{Dir: filepath.Join(dir, "gompc", "art")}
+}
+
+func connectBus(addr string) (*dbus.Conn, error) {
+	if addr == "" {
+		return dbus.ConnectSessionBus()
+	}
+	return dbus.Connect(addr)
+}
+
+// Keeps the bridge attached to MPD, refreshing it on every player, mixer,
+// options or queue change.
+func runMPRIS(ctx context.Context, cfg mpd.Config, b *mpris.Bridge) error {
+	return keepConnected(ctx, "mpris", func(connected func()) error {
+		conn, idle, err := dialPair(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		defer idle.Close()
+		defer b.Attach(ctx, nil)
+
+		qctx, cancel := context.WithTimeout(ctx, opTimeout(cfg))
+		err = b.Attach(qctx, conn)
+		cancel()
+		if err != nil {
+			return err
+		}
+		connected()
+		for {
+			if _, err := idle.Idle(ctx, mprisSubsystems); err != nil {
+				if errors.Is(err, context.Canceled) {
+					return nil
+				}
+				return err
+			}
+			qctx, cancel := context.WithTimeout(ctx, opTimeout(cfg))
+			err := b.Refresh(qctx)
+			cancel()
+			if err != nil {
+				return err
+			}
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+	"github.com/AJMerr/gompc/internal/notify"
+)
+
+func init() {
+	var now bool
+	notifyCmd := &cobra.Command{
+		Use:   "notify",
+		Short: "Show a desktop notification whenever the song changes",
+		Long: "Runs until interrupted, popping up the title, artist, album and cover\n" +
+			"of each new song. The text comes from the notify.summary and notify.body\n" +
+			"templates, which see the song's fields (.Title, .Artist, .Album, .Genre,\n" +
+			".Year, .TrackNo, .Duration, .URI) and the --format helpers. At most one\n" +
+			"notification goes out per notify.min_interval. Set notify.enabled to get\n" +
+			"the same from `gompc tui`. --now notifies about the current song and exits.",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := notifyConfig()
+			if err != nil {
+				return err
+			}
+			n := notify.New(cfg)
+			if now {
+				return withConn(func(ctx context.Context, conn mpd.Conn) error {
+					return n.Notify(ctx, conn)
+				})
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return keepConnected(ctx, "notify", func(connected func()) error {
+				return followPlayer(ctx, mpdConfig(), connected, func(conn mpd.Conn, np mpd.NowPlaying, _ time.Time) error {
+					qctx, cancel := context.WithTimeout(ctx, opTimeout(mpdConfig()))
+					defer cancel()
+					if err := n.Observe(qctx, conn, np); err != nil {
+						fmt.Fprintln(os.Stderr, "notify:", err)
+					}
+					return nil
+				})
+			})
+		},
+	}
+	notifyCmd.Flags().BoolVar(&now, "now", false, "Notify about the current song and exit")
+	viper.SetDefault("notify.summary", notify.DefaultSummary)
+	viper.SetDefault("notify.body", notify.DefaultBody)
+	viper.SetDefault("notify.min_interval", "2s")
+	rootCmd.AddCommand(notifyCmd)
+}
+
+// [notify] settings, shared with the TUI.
+func notifyConfig() (notify.Config, error) {
+	cfg := notify.Config{Art: artCache()}
+	var err error
+	if cfg.Summary, err = configTemplate("notify.summary"); err != nil {
+		return cfg, err
+	}
+	if cfg.Body, err = configTemplate("notify.body"); err != nil {
+		return cfg, err
+	}
+	if cfg.MinInterval, err = configDuration("notify.min_interval"); err != nil {
+		return cfg, err
+	}
+	if cfg.Timeout, err = configDuration("notify.timeout"); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// A template from the config, with the --format helpers.
+func configTemplate(key string) (*template.Template, error) {
+	t, err := template.New(key).Funcs(templateFuncs).Parse(viper.GetString(key))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+	return t, nil
+}
+
+// A Go duration from the config; 0 when unset.
+func configDuration(key string) (time.Duration, error) {
+	s := viper.GetString(key)
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+)
+
+type outputView struct {
+	ID         int               `json:"id"`
+	Name       string            `json:"name"`
+	Plugin     string            `json:"plugin"`
+	Enabled    bool              `json:"enabled"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+func init() {
+	outputsCmd := &cobra.Command{
+		Use:          "outputs",
+		Short:        "List audio outputs",
+		Long:         "List audio outputs. IDs are MPD's own output ids (starting at 0).",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withConn(func(ctx context.Context, conn mpd.Conn) error {
+				outs, err := conn.Outputs(ctx)
+				if err != nil {
+					return err
+				}
+				if jsonFlag(cmd) {
+					views := make([]outputView, len(outs))
+					for i, o := range outs {
+						views[i] = outputView(o)
+					}
+					return writeJSON(views)
+				}
+				for _, o := range outs {
+					fmt.Println(outputText(o))
+				}
+				return nil
+			})
+		},
+	}
+	outputsCmd.Flags().Bool("json", false, "Output JSON")
+
+	for _, a := range []struct {
+		use, short string
+		fn         func(mpd.Conn, context.Context, int) error
+	}{
+		{"enable", "Enable outputs", mpd.Conn.EnableOutput},
+		{"disable", "Disable outputs", mpd.Conn.DisableOutput},
+		{"toggle", "Toggle outputs", mpd.Conn.ToggleOutput},
+	} {
+		fn := a.fn
+		outputsCmd.AddCommand(&cobra.Command{
+			Use:          a.use + " <id|name>...",
+			Short:        a.short,
+			Args:         cobra.MinimumNArgs(1),
+			SilenceUsage: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return withConn(func(ctx context.Context, conn mpd.Conn) error {
+					outs, err := conn.Outputs(ctx)
+					if err != nil {
+						return err
+					}
+					for _, arg := range args {
+						o, err := findOutput(outs, arg)
+						if err != nil {
+							return err
+						}
+						if err := fn(conn, ctx, o.ID); err != nil {
+							return err
+						}
+					}
+					return nil
+				})
+			},
+		})
+	}
+
+	outputsCmd.AddCommand(&cobra.Command{
+		Use:          "set <id|name> <attribute> <value>",
+		Short:        "Set a runtime attribute of an output",
+		Args:         cobra.ExactArgs(3),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withConn(func(ctx context.Context, conn mpd.Conn) error {
+				outs, err := conn.Outputs(ctx)
+				if err != nil {
+					return err
+				}
+				o, err := findOutput(outs, args[0])
+				if err != nil {
+					return err
+				}
+				return conn.OutputSet(ctx, o.ID, args[1], args[2])
+			})
+		},
+	})
+
+	rootCmd.AddCommand(outputsCmd)
+}
+
+// Matches an output by id, then by name (case-insensitive).
+func findOutput(outs []mpd.Output, arg string) (mpd.Output, error) {
+	if id, err := strconv.Atoi(arg); err == nil {
+		for _, o := range outs {
+			if o.ID == id {
+				return o, nil
+			}
+		}
+	}
+	for _, o := range outs {
+		if strings.EqualFold(o.Name, arg) {
+			return o, nil
+		}
+	}
+	return mpd.Output{}, fmt.Errorf("no output %q", arg)
+}
+
+// "0 ALSA [alsa] enabled (dop=0)"
+func outputText(o mpd.Output) string {
+	state := "disabled"
+	if o.Enabled {
+		state = "enabled"
+	}
+	s := fmt.Sprintf("%d %s [%s] %s", o.ID, o.Name, o.Plugin, state)
+	if len(o.Attributes) > 0 {
+		keys := make([]string, 0, len(o.Attributes))
+		for k := range o.Attributes {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for i, k := range keys {
+			keys[i] = k + "=" + o.Attributes[k]
+		}
+		s += " (" + strings.Join(keys, ", ") + ")"
+	}
+	return s
+}
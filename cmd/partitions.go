@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+)
+
+func init() {
+	partitionsCmd := &cobra.Command{
+		Use:   "partitions",
+		Short: "List MPD partitions; the one in use is marked with >",
+		Long: "Partitions (MPD 0.22+) are independent queues and players inside one\n" +
+			"daemon, each with its own outputs. Every command works on the partition\n" +
+			"named by --partition or mpd.partition, \"default\" when unset.",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withConn(func(ctx context.Context, conn mpd.Conn) error {
+				names, err := conn.ListPartitions(ctx)
+				if err != nil {
+					return err
+				}
+				if jsonFlag(cmd) {
+					return writeJSON(names)
+				}
+				cur := currentPartition()
+				for _, name := range names {
+					mark := " "
+					if name == cur {
+						mark = ">"
+					}
+					fmt.Println(mark, name)
+				}
+				return nil
+			})
+		},
+	}
+	partitionsCmd.Flags().Bool("json", false, "Output JSON")
+
+	for _, a := range []struct {
+		use, short string
+		fn         func(mpd.Conn, context.Context, string) error
+	}{
+		{"new", "Create partitions", mpd.Conn.NewPartition},
+		{"delete", "Delete partitions (not default, nor one in use)", mpd.Conn.DelPartition},
+	} {
+		fn := a.fn
+		partitionsCmd.AddCommand(&cobra.Command{
+			Use:          a.use + " <name>...",
+			Short:        a.short,
+			Args:         cobra.MinimumNArgs(1),
+			SilenceUsage: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return withConn(func(ctx context.Context, conn mpd.Conn) error {
+					for _, name := range args {
+						if err := fn(conn, ctx, name); err != nil {
+							return fmt.Errorf("%s: %w", name, err)
+						}
+					}
+					return nil
+				})
+			},
+		})
+	}
+
+	partitionsCmd.AddCommand(&cobra.Command{
+		Use:   "move-output <id|name>...",
+		Short: "Move outputs into the current partition",
+		Long: "Moves outputs into the partition named by --partition, e.g.\n" +
+			"  gompc --partition kitchen partitions move-output Kitchen",
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withConn(func(ctx context.Context, conn mpd.Conn) error {
+				outs, err := conn.Outputs(ctx)
+				if err != nil {
+					return err
+				}
+				for _, arg := range args {
+					o, err := findOutput(outs, arg)
+					if err != nil {
+						return err
+					}
+					if err := conn.MoveOutput(ctx, o.Name); err != nil {
+						return fmt.Errorf("%s: %w", o.Name, err)
+					}
+				}
+				return nil
+			})
+		},
+	})
+
+	rootCmd.AddCommand(partitionsCmd)
+}
+
+func currentPartition() string {
+	if p := mpdConfig().Partition; p != "" {
+		return p
+	}
+	return "default"
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/AJMerr/gompc/internal/app"
+	"github.com/AJMerr/gompc/internal/mpd"
+)
+
+// Named servers live in [profiles.<name>] tables with the same keys as
+// [mpd] (host, port, timeout_ms, partition, music_dir). --profile,
+// $GOMPC_PROFILE or a top-level profile = "name" picks one; --host and
+// friends still win. A remote profile needs its own music_dir.
+
+func profileNames() []string {
+	names := make([]string, 0, len(viper.GetStringMap("profiles")))
+	for name := range viper.GetStringMap("profiles") {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// [mpd] as configured, before a profile is layered over it. Profiles
+// start from it, so another profile's settings never leak into one.
+var baseMPD struct {
+	cfg      mpd.Config
+	musicDir string
+}
+
+// The server a profile points at. Unset host, port and timeout_ms take
+// the [mpd] ones; partition doesn't carry over.
+func profileConfig(name string) mpd.Config {
+	key := "profiles." + name + "."
+	cfg := baseMPD.cfg
+	if viper.IsSet(key + "host") {
+		cfg.Host = viper.GetString(key + "host")
+	}
+	if viper.IsSet(key + "port") {
+		cfg.Port = viper.GetInt(key + "port")
+	}
+	if viper.IsSet(key + "timeout_ms") {
+		cfg.Timeout = time.Duration(viper.GetInt(key+"timeout_ms")) * time.Millisecond
+	}
+	cfg.Partition = viper.GetString(key + "partition")
+	return cfg
+}
+
+// A profile's music_dir. Unset, it is the [mpd] one only for a server on
+// this machine; a remote server's paths aren't ours to read.
+func profileMusicDir(name string, cfg mpd.Config) string {
+	key := "profiles." + name + ".music_dir"
+	if viper.IsSet(key) {
+		return viper.GetString(key)
+	}
+	if isLoopback(cfg.Host) || strings.HasPrefix(cfg.Host, "/") || strings.HasPrefix(cfg.Host, "@") {
+		return baseMPD.musicDir
+	}
+	return ""
+}
+
+// Layers the selected profile over [mpd] so every mpd.* lookup sees it.
+// Called once the config file is read.
+func applyProfile() error {
+	baseMPD.cfg, baseMPD.musicDir = mpdConfig(), viper.GetString("mpd.music_dir")
+	name := viper.GetString("profile")
+	if name == "" {
+		return nil
+	}
+	if !viper.IsSet("profiles." + name) {
+		if names := profileNames(); len(names) > 0 {
+			return fmt.Errorf("unknown profile %q (have %s)", name, strings.Join(names, ", "))
+		}
+		return fmt.Errorf("unknown profile %q (no [profiles] in %s)", name, viper.ConfigFileUsed())
+	}
+	cfg := profileConfig(name)
+	table := map[string]any{
+		"host":       cfg.Host,
+		"port":       cfg.Port,
+		"timeout_ms": ms(cfg.Timeout),
+		"music_dir":  profileMusicDir(name, cfg),
+		"partition":  cfg.Partition, // "" too, or [mpd]'s would carry over
+	}
+	return viper.MergeConfigMap(map[string]any{"mpd": table})
+}
+
+// Every profile, for the TUI's Servers tab.
+func tuiProfiles() []app.Profile {
+	var out []app.Profile
+	for _, name := range profileNames() {
+		cfg := profileConfig(name)
+		out = append(out, app.Profile{Name: name, Cfg: cfg, MusicDir: expandHome(profileMusicDir(name, cfg))})
+	}
+	return out
+}
@@ -3,22 +3,79 @@ package cmd
 import (
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "gompc",
 	Short: "Go MPC Utilities and Doctor check",
+
+	SilenceErrors: true, // CheckErr prints them
 }
 
 // Called by main.go
 func Execute() {
+	rootCmd.SetArgs(negativeArgs(os.Args[1:]))
 	cobra.CheckErr(rootCmd.Execute())
 }
 
+var negNumber = regexp.MustCompile(`^-[0-9]`)
+
+// "seek -10" and "volume -5" take negative numbers, which pflag would read
+// as shorthand flags; move them after a "--".
+func negativeArgs(args []string) []string {
+	sub := subcommandIndex(args)
+	if sub < 0 || (args[sub] != "seek" && args[sub] != "volume") {
+		return args
+	}
+	var rest, neg []string
+	for _, a := range args[sub+1:] {
+		if negNumber.MatchString(a) {
+			neg = append(neg, a)
+		} else {
+			rest = append(rest, a)
+		}
+	}
+	if len(neg) == 0 {
+		return args
+	}
+	out := append(append([]string(nil), args[:sub+1]...), rest...)
+	return append(append(out, "--"), neg...)
+}
+
+// Index of the subcommand name: the first argument that isn't a root flag
+// or a root flag's value; -1 if there is none.
+func subcommandIndex(args []string) int {
+	flags := rootCmd.PersistentFlags()
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--":
+			return -1
+		case !strings.HasPrefix(a, "-") || a == "-":
+			return i
+		case strings.Contains(a, "="):
+			continue
+		}
+		var f *pflag.Flag
+		if name, ok := strings.CutPrefix(a, "--"); ok {
+			f = flags.Lookup(name)
+		} else if len(a) == 2 {
+			f = flags.ShorthandLookup(a[1:])
+		}
+		if f != nil && f.NoOptDefVal == "" {
+			i++ // its value
+		}
+	}
+	return -1
+}
+
 func init() {
 	// Defaults
 	viper.SetDefault("mpd.host", "127.0.0.1")
@@ -29,24 +86,31 @@ func init() {
 	rootCmd.PersistentFlags().String("host", "", "MPD host (env MPD_HOST)")
 	rootCmd.PersistentFlags().Int("port", 0, "MPD port (env MPD_PORT)")
 	rootCmd.PersistentFlags().Int("timeout", 0, "Timeout ms (env GOMPC_TIMEOUT_MS)")
+	rootCmd.PersistentFlags().String("partition", "", "MPD partition (env MPD_PARTITION)")
 	rootCmd.PersistentFlags().String("config", defaultConfigPath(), "Path to config file")
+	rootCmd.PersistentFlags().String("profile", "", "Server from [profiles.<name>] (env GOMPC_PROFILE)")
 
 	_ = viper.BindPFlag("mpd.host", rootCmd.PersistentFlags().Lookup("host"))
 	_ = viper.BindPFlag("mpd.port", rootCmd.PersistentFlags().Lookup("port"))
 	_ = viper.BindPFlag("mpd.timeout_ms", rootCmd.PersistentFlags().Lookup("timeout"))
+	_ = viper.BindPFlag("mpd.partition", rootCmd.PersistentFlags().Lookup("partition"))
 	_ = viper.BindPFlag("config_path", rootCmd.PersistentFlags().Lookup("config"))
+	_ = viper.BindPFlag("profile", rootCmd.PersistentFlags().Lookup("profile"))
 
 	// env
 	_ = viper.BindEnv("mpd.host", "MPD_HOST")
 	_ = viper.BindEnv("mpd.port", "MPD_PORT")
+	_ = viper.BindEnv("mpd.partition", "MPD_PARTITION")
 	_ = viper.BindEnv("mpd.timeout_ms", "YOURAPP_TIMEOUT_MS")
+	_ = viper.BindEnv("profile", "GOMPC_PROFILE")
 
-	// Loads TOML config if present
-	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+	// Loads TOML config if present, then the selected profile
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
 		path := viper.GetString("config_path")
 		viper.SetConfigFile(path)
 		viper.SetConfigType("toml")
 		_ = viper.ReadInConfig()
+		return applyProfile()
 	}
 }
 
@@ -58,6 +122,15 @@ func defaultConfigPath() string {
 	return filepath.Join(home, ".config", "gompc", "config.toml")
 }
 
+// $XDG_DATA_HOME/gompc, or ~/.local/share/gompc: history and spooled scrobbles.
+func dataDir() string {
+	if x := os.Getenv("XDG_DATA_HOME"); x != "" {
+		return filepath.Join(x, "gompc")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".local", "share", "gompc")
+}
+
 func ms(d time.Duration) int64 {
 	return d.Milliseconds()
 }
@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+	"github.com/AJMerr/gompc/internal/plays"
+	"github.com/AJMerr/gompc/internal/scrobble"
+)
+
+func init() {
+	scrobbleCmd := &cobra.Command{
+		Use:   "scrobble",
+		Short: "Scrobble played songs to Last.fm and/or ListenBrainz",
+		Long: "Runs until interrupted, sending now-playing updates and scrobbling\n" +
+			"songs heard for half their length or 4 minutes. Services are enabled by\n" +
+			"their credentials in config.toml:\n\n" +
+			"  [scrobble.listenbrainz]\n" +
+			"  token = \"...\"          # url = \"...\" for another server\n\n" +
+			"  [scrobble.lastfm]\n" +
+			"  api_key = \"...\"\n" +
+			"  secret = \"...\"\n" +
+			"  session_key = \"...\"    # from `gompc scrobble login`\n\n" +
+			"Scrobbles are spooled under " + filepath.Join(dataDir(), "scrobble") + "\n" +
+			"until the service takes them, and retried with backoff while offline.",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scs, err := scrobblers()
+			if err != nil {
+				return err
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return runScrobbler(ctx, mpdConfig(), scs)
+		},
+	}
+
+	pendingCmd := &cobra.Command{
+		Use:          "pending",
+		Short:        "List scrobbles waiting to be sent",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scs, err := scrobblers()
+			if err != nil {
+				return err
+			}
+			all := map[string][]scrobble.Listen{}
+			for _, sc := range scs {
+				ls, err := sc.Pending()
+				if err != nil {
+					return err
+				}
+				all[sc.Name()] = ls
+			}
+			if jsonFlag(cmd) {
+				return writeJSON(all)
+			}
+			for _, sc := range scs {
+				ls := all[sc.Name()]
+				fmt.Printf("%s: %d pending\n", sc.Name(), len(ls))
+				for _, l := range ls {
+					fmt.Printf("  %s %s - %s\n", l.At.Local().Format("2006-01-02 15:04"), l.Artist, l.Title)
+				}
+			}
+			return nil
+		},
+	}
+	pendingCmd.Flags().Bool("json", false, "Output JSON")
+
+	flushCmd := &cobra.Command{
+		Use:          "flush",
+		Short:        "Send pending scrobbles now",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scs, err := scrobblers()
+			if err != nil {
+				return err
+			}
+			var errs []error
+			for _, sc := range scs {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				n, err := sc.Flush(ctx, true)
+				cancel()
+				fmt.Printf("%s: sent %d\n", sc.Name(), n)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", sc.Name(), err))
+				}
+			}
+			return errors.Join(errs...)
+		},
+	}
+
+	loginCmd := &cobra.Command{
+		Use:   "login",
+		Short: "Get a Last.fm session key",
+		Long: "Authorises gompc with Last.fm (or a clone at scrobble.lastfm.url) using\n" +
+			"api_key and secret from [scrobble.lastfm], and prints the session_key\n" +
+			"to add there.",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			lf := lastfmService()
+			if lf.APIKey == "" || lf.Secret == "" {
+				return errors.New("set scrobble.lastfm.api_key and scrobble.lastfm.secret first")
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			token, err := lf.Token(ctx)
+			cancel()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Allow access at\n\n  %s\n\nthen press Enter.", lf.AuthURL(viper.GetString("scrobble.lastfm.auth_url"), token))
+			_, _ = bufio.NewReader(os.Stdin).ReadString('\n')
+
+			ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			key, user, err := lf.Session(ctx, token)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("\nLogged in as %s. Add to [scrobble.lastfm]:\n\n  session_key = %q\n", user, key)
+			return nil
+		},
+	}
+
+	scrobbleCmd.AddCommand(pendingCmd, flushCmd, loginCmd)
+	rootCmd.AddCommand(scrobbleCmd)
+}
+
+func lastfmService() *scrobble.LastFM {
+	return &scrobble.LastFM{
+		URL:        viper.GetString("scrobble.lastfm.url"),
+		APIKey:     viper.GetString("scrobble.lastfm.api_key"),
+		Secret:     viper.GetString("scrobble.lastfm.secret"),
+		SessionKey: viper.GetString("scrobble.lastfm.session_key"),
+	}
+}
+
+// A scrobbler for each service with credentials in the config.
+func scrobblers() ([]*scrobble.Scrobbler, error) {
+	var svcs []scrobble.Service
+	if token := viper.GetString("scrobble.listenbrainz.token"); token != "" {
+		svcs = append(svcs, &scrobble.ListenBrainz{
+			URL:   viper.GetString("scrobble.listenbrainz.url"),
+			Token: token,
+		})
+	}
+	if lf := lastfmService(); lf.SessionKey != "" {
+		svcs = append(svcs, lf)
+	}
+	if len(svcs) == 0 {
+		return nil, errors.New("no scrobbling service configured (see gompc scrobble --help)")
+	}
+	dir := filepath.Join(dataDir(), "scrobble")
+	scs := make([]*scrobble.Scrobbler, len(svcs))
+	for i, svc := range svcs {
+		scs[i] = scrobble.New(svc, scrobble.NewSpool(filepath.Join(dir, svc.Name()+".jsonl")))
+	}
+	return scs, nil
+}
+
+// How often spooled scrobbles are retried when nothing else happens
+const scrobbleRetryEvery = 30 * time.Second
+
+func runScrobbler(ctx context.Context, cfg mpd.Config, scs []*scrobble.Scrobbler) error {
+	logf := func(format string, a ...any) {
+		fmt.Printf("%s "+format+"\n", append([]any{time.Now().Format("15:04:05")}, a...)...)
+	}
+	// Sends what's spooled, on start and whenever a backoff runs out.
+	flush := func(sc *scrobble.Scrobbler) {
+		n, err := sc.Flush(ctx, false)
+		if n > 0 {
+			logf("%s: sent %d", sc.Name(), n)
+		}
+		if err != nil && ctx.Err() == nil {
+			logf("%s: %v", sc.Name(), err)
+			if at := sc.RetryAt(); !at.IsZero() {
+				logf("%s: retrying at %s", sc.Name(), at.Format("15:04:05"))
+			}
+		}
+	}
+	go func() {
+		for _, sc := range scs {
+			flush(sc)
+		}
+		t := time.NewTicker(scrobbleRetryEvery)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				for _, sc := range scs {
+					if at := sc.RetryAt(); !at.IsZero() && time.Now().After(at) {
+						flush(sc)
+					}
+				}
+			}
+		}
+	}()
+
+	var tr plays.Tracker
+	var announced string // URI and queue id of the last now-playing
+	return keepConnected(ctx, "scrobble", func(connected func()) error {
+		return followPlayer(ctx, cfg, connected, func(_ mpd.Conn, np mpd.NowPlaying, at time.Time) error {
+			if p, ok := tr.Observe(np, at); ok && scrobble.Counts(p) {
+				l := scrobble.FromSong(p.Song, p.Started)
+				for _, sc := range scs {
+					if err := sc.Add(l); err != nil {
+						return err
+					}
+					logf("%s: scrobbling %s - %s", sc.Name(), l.Artist, l.Title)
+					go flush(sc)
+				}
+			}
+			key := fmt.Sprintf("%s#%d", np.URI, np.SongID)
+			if np.State != "play" || key == announced || !scrobble.Submittable(np) {
+				return nil
+			}
+			announced = key
+			l := scrobble.FromSong(np, at.Add(-np.Elapsed))
+			for _, sc := range scs {
+				go func() {
+					nctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+					defer cancel()
+					if err := sc.NowPlaying(nctx, l); err != nil && ctx.Err() == nil {
+						logf("%s: now playing: %v", sc.Name(), err)
+					}
+				}()
+			}
+			return nil
+		})
+	})
+}
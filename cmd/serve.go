@@ -0,0 +1,356 @@
+package cmd
+
+import (
+	"context"
+	"crypto/subtle"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+)
+
+//go:embed web
+var webFiles embed.FS
+
+func init() {
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve a REST API and web remote for MPD",
+		Long: "Serves a JSON API over MPD and a small web remote at /. Routes:\n\n" +
+			"  GET  /api/status                 GET/PUT /api/volume\n" +
+			"  POST /api/play|pause|toggle|stop|next|prev|seek\n" +
+			"  GET/POST/DELETE /api/queue       DELETE /api/queue/{id}\n" +
+			"  POST /api/queue/{id}/play        POST /api/queue/{id}/move\n" +
+			"  GET  /api/library?path=          GET  /api/search?q=&tag=\n" +
+			"  GET  /api/playlists              GET/PUT/DELETE /api/playlists/{name}\n" +
+			"  POST /api/playlists/{name}/load  GET  /api/albumart?uri=\n" +
+			"  GET  /api/events                 (Server-Sent Events of MPD changes)\n\n" +
+			"With --token (or serve.token), API calls need \"Authorization: Bearer\n" +
+			"<token>\" or ?token=. Listens on localhost unless told otherwise; use\n" +
+			"--listen :6680 to reach it from other devices.\n\n" +
+			"Calls other than GET need Content-Type: application/json and no Origin\n" +
+			"but this server's, so other web pages can't drive MPD. Without a token\n" +
+			"the Host header must also be the listen address, an IP or localhost.",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			addr, token := viper.GetString("serve.listen"), viper.GetString("serve.token")
+			if host, _, err := net.SplitHostPort(addr); err == nil && token == "" && !isLoopback(host) {
+				fmt.Fprintln(os.Stderr, "serve: warning: no --token; anyone who can reach", addr, "controls MPD")
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			s := newAPIServer(mpdConfig(), token)
+			s.listen, _, _ = net.SplitHostPort(addr)
+			go s.followEvents(ctx)
+			srv := &http.Server{Addr: addr, Handler: s.routes(), ReadHeaderTimeout: 10 * time.Second}
+			go func() {
+				<-ctx.Done()
+				sctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				_ = srv.Shutdown(sctx)
+			}()
+			fmt.Printf("serving on http://%s\n", addr)
+			if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+	}
+	viper.SetDefault("serve.listen", "localhost:6680")
+	serveCmd.Flags().String("listen", "", "Address to listen on (default localhost:6680)")
+	serveCmd.Flags().String("token", "", "Bearer token required for API calls (env GOMPC_SERVE_TOKEN)")
+	_ = viper.BindPFlag("serve.listen", serveCmd.Flags().Lookup("listen"))
+	_ = viper.BindPFlag("serve.token", serveCmd.Flags().Lookup("token"))
+	_ = viper.BindEnv("serve.token", "GOMPC_SERVE_TOKEN")
+	rootCmd.AddCommand(serveCmd)
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Subsystems pushed to /api/events
+var serveSubsystems = []string{"player", "mixer", "options", "playlist", "stored_playlist", "database", "update", "output", "sticker"}
+
+type apiServer struct {
+	cfg    mpd.Config
+	token  string
+	listen string // host part of the listen address, for the Host check
+
+	mu   sync.Mutex // held for a whole request
+	conn mpd.Conn   // shared by all requests; dialled on demand
+
+	subsMu sync.Mutex
+	subs   map[chan sseEvent]struct{}
+}
+
+type sseEvent struct {
+	Name string
+	Data any
+}
+
+func newAPIServer(cfg mpd.Config, token string) *apiServer {
+	return &apiServer{cfg: cfg, token: token, subs: map[chan sseEvent]struct{}{}}
+}
+
+// Runs fn on the shared connection, dialling MPD if there is none. One
+// request runs at a time, so multi-command handlers don't interleave. The
+// connection is dropped on anything but an MPD error. MPD hangs up on
+// clients idle past its connection_timeout, so a kept connection failing
+// that way is redialled and fn run once more.
+func (s *apiServer) with(ctx context.Context, fn func(ctx context.Context, conn mpd.Conn) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for retried := false; ; retried = true {
+		kept := s.conn != nil
+		if !kept {
+			c, err := mpd.NewClient().Connect(ctx, s.cfg)
+			if err != nil {
+				return err
+			}
+			s.conn = c
+		}
+		err := fn(ctx, s.conn)
+		if err == nil || isACK(err) || errors.Is(err, mpd.ErrNoArt) || ctx.Err() != nil {
+			return err
+		}
+		s.conn.Close()
+		s.conn = nil
+		if !kept || retried {
+			return err
+		}
+	}
+}
+
+func isACK(err error) bool { return strings.HasPrefix(err.Error(), "ACK ") }
+
+// Handler for a JSON endpoint: fn's result is the body, nil is 204.
+func (s *apiServer) api(fn func(ctx context.Context, conn mpd.Conn, r *http.Request) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), max(opTimeout(s.cfg), 10*time.Second))
+		defer cancel()
+		var v any
+		err := s.with(ctx, func(ctx context.Context, conn mpd.Conn) error {
+			var err error
+			v, err = fn(ctx, conn, r)
+			return err
+		})
+		if err != nil {
+			apiError(w, err)
+			return
+		}
+		if v == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		replyJSON(w, http.StatusOK, v)
+	}
+}
+
+// A request the client got wrong.
+type badRequest struct{ msg string }
+
+func (e badRequest) Error() string { return e.msg }
+
+func apiError(w http.ResponseWriter, err error) {
+	status := http.StatusBadGateway
+	var bad badRequest
+	switch {
+	case errors.As(err, &bad):
+		status = http.StatusBadRequest
+	case errors.Is(err, mpd.ErrNoArt), strings.HasPrefix(err.Error(), "ACK [50@"):
+		status = http.StatusNotFound
+	case isACK(err):
+		status = http.StatusBadRequest
+	}
+	replyJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func replyJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// Decodes a JSON body into v; an empty body leaves v alone.
+func readBody(r *http.Request, v any) error {
+	err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(v)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return badRequest{"bad request body: " + err.Error()}
+	}
+	return nil
+}
+
+// Checks the bearer token (or ?token= for EventSource and <img>).
+func (s *apiServer) auth(next http.Handler) http.Handler {
+	if s.token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			got = r.URL.Query().Get("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="gompc"`)
+			replyJSON(w, http.StatusUnauthorized, map[string]string{"error": "missing or wrong token"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Keeps other web pages off the API. A page can send a no-cors fetch or a
+// form POST to localhost, but not with a JSON Content-Type or without its
+// own Origin; DNS rebinding gets past that, but not the Host check. With a
+// token neither matters, and Host may be any name the server is reached by.
+func (s *apiServer) guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.token == "" && !s.knownHost(r.Host) {
+			replyJSON(w, http.StatusForbidden, map[string]string{"error": "unexpected Host " + r.Host})
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct != "application/json" {
+				replyJSON(w, http.StatusUnsupportedMediaType, map[string]string{"error": "Content-Type must be application/json"})
+				return
+			}
+			if o := r.Header.Get("Origin"); o != "" && !sameOrigin(o, r.Host) {
+				replyJSON(w, http.StatusForbidden, map[string]string{"error": "cross-origin request"})
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Whether a Host header names this server: the listen host, an IP literal
+// (which rebinding can't produce) or a loopback name.
+func (s *apiServer) knownHost(hostport string) bool {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = hostport
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	return (s.listen != "" && strings.EqualFold(host, s.listen)) ||
+		net.ParseIP(strings.Trim(host, "[]")) != nil || isLoopback(host)
+}
+
+func sameOrigin(origin, host string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && strings.EqualFold(u.Host, host)
+}
+
+func (s *apiServer) routes() http.Handler {
+	api := http.NewServeMux()
+	s.routeAPI(api)
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/", s.guard(s.auth(api)))
+	ui, _ := fs.Sub(webFiles, "web")
+	mux.Handle("/", http.FileServerFS(ui))
+	return mux
+}
+
+// Idles on its own connection and fans changes out to /api/events
+// subscribers, reconnecting like watch.
+func (s *apiServer) followEvents(ctx context.Context) {
+	_ = keepConnected(ctx, "serve", func(connected func()) error {
+		idle, err := mpd.NewClient().Connect(ctx, s.cfg)
+		if err != nil {
+			return err
+		}
+		defer idle.Close()
+		connected()
+		s.publish(sseEvent{"connected", struct{}{}})
+		defer s.publish(sseEvent{"disconnected", struct{}{}})
+		for {
+			changed, err := idle.Idle(ctx, serveSubsystems)
+			if err != nil {
+				return err
+			}
+			s.publish(sseEvent{"changed", map[string][]string{"changed": changed}})
+		}
+	})
+}
+
+func (s *apiServer) subscribe() (chan sseEvent, func()) {
+	ch := make(chan sseEvent, 16)
+	s.subsMu.Lock()
+	s.subs[ch] = struct{}{}
+	s.subsMu.Unlock()
+	return ch, func() {
+		s.subsMu.Lock()
+		delete(s.subs, ch)
+		s.subsMu.Unlock()
+	}
+}
+
+// Sends ev to every subscriber; a client too slow to keep up misses it.
+func (s *apiServer) publish(ev sseEvent) {
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+	for ch := range s.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// Keeps idle proxies from closing quiet event streams
+const ssePing = 30 * time.Second
+
+func (s *apiServer) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		replyJSON(w, http.StatusInternalServerError, map[string]string{"error": "streaming unsupported"})
+		return
+	}
+	ch, unsubscribe := s.subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+	flusher.Flush()
+
+	ping := time.NewTicker(ssePing)
+	defer ping.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ping.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case ev := <-ch:
+			b, _ := json.Marshal(ev.Data)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Name, b)
+		}
+		flusher.Flush()
+	}
+}
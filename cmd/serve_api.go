@@ -0,0 +1,316 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+)
+
+type handler = func(ctx context.Context, conn mpd.Conn, r *http.Request) (any, error)
+
+func (s *apiServer) routeAPI(mux *http.ServeMux) {
+	route := func(pattern string, fn handler) { mux.HandleFunc(pattern, s.api(fn)) }
+
+	// Player
+	route("GET /api/status", apiStatus)
+	route("POST /api/play", apiPlay)
+	route("POST /api/pause", simple(func(c mpd.Conn, ctx context.Context) error { return c.Pause(ctx, true) }))
+	route("POST /api/toggle", simple(mpd.Conn.TogglePause))
+	route("POST /api/stop", simple(mpd.Conn.Stop))
+	route("POST /api/next", simple(mpd.Conn.Next))
+	route("POST /api/prev", simple(mpd.Conn.Prev))
+	route("POST /api/seek", apiSeek)
+	route("GET /api/volume", apiVolume)
+	route("PUT /api/volume", apiSetVolume)
+
+	// Queue
+	route("GET /api/queue", apiQueue)
+	route("POST /api/queue", apiQueueAdd)
+	route("DELETE /api/queue", simple(mpd.Conn.QueueClear))
+	route("DELETE /api/queue/{id}", withID(mpd.Conn.QueueDeleteID))
+	route("POST /api/queue/{id}/play", withID(mpd.Conn.PlayID))
+	route("POST /api/queue/{id}/move", apiQueueMove)
+
+	// Library
+	route("GET /api/library", apiLibrary)
+	route("GET /api/search", apiSearch)
+	route("GET /api/playlists", apiPlaylists)
+	route("GET /api/playlists/{name}", apiPlaylist)
+	route("PUT /api/playlists/{name}", apiPlaylistSave)
+	route("POST /api/playlists/{name}/load", withName(mpd.Conn.PlaylistLoad))
+	route("DELETE /api/playlists/{name}", withName(mpd.Conn.PlaylistRemove))
+
+	mux.HandleFunc("GET /api/albumart", s.albumArt)
+	mux.HandleFunc("GET /api/events", s.events)
+}
+
+// Endpoints that run one command and return nothing
+func simple(fn func(mpd.Conn, context.Context) error) handler {
+	return func(ctx context.Context, c mpd.Conn, r *http.Request) (any, error) {
+		return nil, fn(c, ctx)
+	}
+}
+
+func withID(fn func(mpd.Conn, context.Context, int) error) handler {
+	return func(ctx context.Context, c mpd.Conn, r *http.Request) (any, error) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			return nil, badRequest{"bad queue id " + strconv.Quote(r.PathValue("id"))}
+		}
+		return nil, fn(c, ctx, id)
+	}
+}
+
+func withName(fn func(mpd.Conn, context.Context, string) error) handler {
+	return func(ctx context.Context, c mpd.Conn, r *http.Request) (any, error) {
+		return nil, fn(c, ctx, r.PathValue("name"))
+	}
+}
+
+func trackViews(ts []mpd.Track) []trackView {
+	out := make([]trackView, len(ts))
+	for i, t := range ts {
+		out[i] = newTrackView(t)
+	}
+	return out
+}
+
+func apiStatus(ctx context.Context, c mpd.Conn, r *http.Request) (any, error) {
+	st, err := c.PlayerStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := c.CurrentSong(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newStatusView(st, cur), nil
+}
+
+// {"position": n} plays the nth queue entry (1-based), {"id": n} a queue
+// id; neither resumes or starts playback.
+func apiPlay(ctx context.Context, c mpd.Conn, r *http.Request) (any, error) {
+	var body struct {
+		Position int `json:"position"`
+		ID       int `json:"id"`
+	}
+	if err := readBody(r, &body); err != nil {
+		return nil, err
+	}
+	switch {
+	case body.ID > 0:
+		return nil, c.PlayID(ctx, body.ID)
+	case body.Position > 0:
+		return nil, c.PlayPos(ctx, body.Position-1)
+	}
+	st, err := c.PlayerStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if st.State == "pause" {
+		return nil, c.Resume(ctx)
+	}
+	return nil, c.PlayPos(ctx, max(st.Song, 0))
+}
+
+func apiSeek(ctx context.Context, c mpd.Conn, r *http.Request) (any, error) {
+	var body struct {
+		PositionMS *int64 `json:"position_ms"`
+	}
+	if err := readBody(r, &body); err != nil {
+		return nil, err
+	}
+	if body.PositionMS == nil || *body.PositionMS < 0 {
+		return nil, badRequest{"want {\"position_ms\": n}"}
+	}
+	return nil, c.SeekCur(ctx, time.Duration(*body.PositionMS)*time.Millisecond)
+}
+
+func apiVolume(ctx context.Context, c mpd.Conn, r *http.Request) (any, error) {
+	st, err := c.PlayerStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]int{"volume": st.Volume}, nil
+}
+
+func apiSetVolume(ctx context.Context, c mpd.Conn, r *http.Request) (any, error) {
+	var body struct {
+		Volume *int `json:"volume"`
+	}
+	if err := readBody(r, &body); err != nil {
+		return nil, err
+	}
+	if body.Volume == nil || *body.Volume < 0 || *body.Volume > 100 {
+		return nil, badRequest{"want {\"volume\": 0-100}"}
+	}
+	if err := c.SetVolume(ctx, *body.Volume); err != nil {
+		return nil, err
+	}
+	return map[string]int{"volume": *body.Volume}, nil
+}
+
+func apiQueue(ctx context.Context, c mpd.Conn, r *http.Request) (any, error) {
+	ts, err := c.QueueList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return trackViews(ts), nil
+}
+
+// {"uris": [...], "play": true} appends songs or directories, optionally
+// playing the first. Returns the new queue ids.
+func apiQueueAdd(ctx context.Context, c mpd.Conn, r *http.Request) (any, error) {
+	var body struct {
+		URIs []string `json:"uris"`
+		Play bool     `json:"play"`
+	}
+	if err := readBody(r, &body); err != nil {
+		return nil, err
+	}
+	if len(body.URIs) == 0 {
+		return nil, badRequest{"want {\"uris\": [...]}"}
+	}
+	ids := []int{}
+	for _, uri := range body.URIs {
+		added, err := queueAddIDs(ctx, c, uri)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, added...)
+	}
+	if body.Play && len(ids) > 0 {
+		if err := c.PlayID(ctx, ids[0]); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// Appends uri, returning the new ids. addid only takes songs, so a
+// directory goes in song by song.
+func queueAddIDs(ctx context.Context, c mpd.Conn, uri string) ([]int, error) {
+	id, err := c.QueueAddID(ctx, uri)
+	if err == nil {
+		return []int{id}, nil
+	}
+	if !strings.HasPrefix(err.Error(), "ACK [50@") {
+		return nil, err
+	}
+	songs, serr := c.SearchFilter(ctx, "(base "+mpd.Quote(uri)+")")
+	if serr != nil || len(songs) == 0 {
+		return nil, err // the "no such song" is the better message
+	}
+	ids := make([]int, 0, len(songs))
+	for _, t := range songs {
+		id, err := c.QueueAddID(ctx, t.URI)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// {"position": n} moves the entry to queue position n (1-based).
+func apiQueueMove(ctx context.Context, c mpd.Conn, r *http.Request) (any, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return nil, badRequest{"bad queue id " + strconv.Quote(r.PathValue("id"))}
+	}
+	var body struct {
+		Position int `json:"position"`
+	}
+	if err := readBody(r, &body); err != nil {
+		return nil, err
+	}
+	if body.Position < 1 {
+		return nil, badRequest{"want {\"position\": n} (1-based)"}
+	}
+	return nil, c.QueueMoveID(ctx, id, body.Position-1)
+}
+
+// One directory of the database, ?path= relative to the music root.
+func apiLibrary(ctx context.Context, c mpd.Conn, r *http.Request) (any, error) {
+	dirs, ts, err := c.LsInfo(ctx, r.URL.Query().Get("path"))
+	if err != nil {
+		return nil, err
+	}
+	if dirs == nil {
+		dirs = []string{}
+	}
+	return struct {
+		Dirs   []string    `json:"dirs"`
+		Tracks []trackView `json:"tracks"`
+	}{dirs, trackViews(ts)}, nil
+}
+
+// ?q= matched against ?tag= (default any), case-insensitively.
+func apiSearch(ctx context.Context, c mpd.Conn, r *http.Request) (any, error) {
+	q := r.URL.Query()
+	if q.Get("q") == "" {
+		return nil, badRequest{"want ?q="}
+	}
+	tag := q.Get("tag")
+	if tag == "" {
+		tag = "any"
+	}
+	ts, err := c.Search(ctx, tag, q.Get("q"))
+	if err != nil {
+		return nil, err
+	}
+	return trackViews(ts), nil
+}
+
+func apiPlaylists(ctx context.Context, c mpd.Conn, r *http.Request) (any, error) {
+	names, err := c.Playlists(ctx)
+	if names == nil {
+		names = []string{}
+	}
+	return names, err
+}
+
+func apiPlaylist(ctx context.Context, c mpd.Conn, r *http.Request) (any, error) {
+	ts, err := c.PlaylistSongs(ctx, r.PathValue("name"))
+	if err != nil {
+		return nil, err
+	}
+	return trackViews(ts), nil
+}
+
+// Saves the queue as the playlist, replacing it.
+func apiPlaylistSave(ctx context.Context, c mpd.Conn, r *http.Request) (any, error) {
+	name := r.PathValue("name")
+	if err := c.PlaylistRemove(ctx, name); err != nil {
+		return nil, err
+	}
+	return nil, c.PlaylistSave(ctx, name)
+}
+
+// Cover of ?uri=, cached by the browser for a day.
+func (s *apiServer) albumArt(w http.ResponseWriter, r *http.Request) {
+	uri := r.URL.Query().Get("uri")
+	if uri == "" {
+		apiError(w, badRequest{"want ?uri="})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), max(opTimeout(s.cfg), 30*time.Second))
+	defer cancel()
+	var data []byte
+	err := s.with(ctx, func(ctx context.Context, conn mpd.Conn) error {
+		var err error
+		data, err = conn.AlbumArt(ctx, uri)
+		return err
+	})
+	if err != nil {
+		apiError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", http.DetectContentType(data))
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	_, _ = w.Write(data)
+}
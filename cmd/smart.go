@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+	"github.com/AJMerr/gompc/internal/smart"
+)
+
+func init() {
+	smartCmd := &cobra.Command{
+		Use:   "smart",
+		Short: "Smart playlists: saved rules evaluated against the library",
+		Long: "Smart playlists are kept in the smart/ directory next to config.toml,\n" +
+			"one <name>.toml each:\n\n" +
+			"  match = \"all\"   # or \"any\"\n" +
+			"  rules = [\"genre is Jazz\", \"rating >= 4\", \"lastplayed not within 30d\"]\n" +
+			"  limit = \"2h\"    # or a track count\n" +
+			"  sort  = \"random\" # or a field, -field for descending\n\n" +
+			"Rules are <field> <op> <value>. Fields: " + strings.Join(smart.Fields(), ", ") + ".\n" +
+			"Text: is, is not, contains, not contains, starts with (case-insensitive).\n" +
+			"Numbers and duration: = != < <= > >=. lastplayed: within, not within\n" +
+			"(30d, 2w, 12h). favourite: is yes/no. Ratings and play counts come from\n" +
+			"the stickers the TUI keeps.",
+	}
+
+	listCmd := &cobra.Command{
+		Use:          "list",
+		Aliases:      []string{"ls"},
+		Short:        "List smart playlists",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ps, err := smart.LoadAll(smartDir())
+			if err != nil {
+				return err
+			}
+			if jsonFlag(cmd) {
+				return writeJSON(ps)
+			}
+			for _, p := range ps {
+				fmt.Printf("%s: %s\n", p.Name, p.Describe())
+			}
+			return nil
+		},
+	}
+	listCmd.Flags().Bool("json", false, "Output JSON")
+
+	var p smart.Playlist
+	var anyRule, force bool
+	newCmd := &cobra.Command{
+		Use:   "new <name> --rule <rule>...",
+		Short: "Create a smart playlist",
+		Example: "  gompc smart new late-jazz --rule 'genre is Jazz' --rule 'rating >= 4' \\\n" +
+			"    --rule 'lastplayed not within 30d' --limit 2h --sort random",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p.Name = args[0]
+			if anyRule {
+				p.Match = "any"
+			}
+			dir := smartDir()
+			if _, err := smart.Load(dir, p.Name); err == nil && !force {
+				return fmt.Errorf("smart playlist %q exists (use --force to replace it)", p.Name)
+			}
+			return smart.Save(dir, p)
+		},
+	}
+	newCmd.Flags().StringArrayVar(&p.Rules, "rule", nil, "Rule, e.g. 'genre is Jazz' (repeatable)")
+	newCmd.Flags().BoolVar(&anyRule, "any", false, "Match any rule instead of all")
+	newCmd.Flags().StringVar(&p.Limit, "limit", "", "Track count or total duration (e.g. 50, 2h)")
+	newCmd.Flags().StringVar(&p.Sort, "sort", "", "random, or a field (-field for descending)")
+	newCmd.Flags().BoolVar(&force, "force", false, "Replace an existing playlist")
+
+	showCmd := &cobra.Command{
+		Use:          "show <name>",
+		Short:        "Print the songs a smart playlist selects right now",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tmpl, err := formatFlag(cmd)
+			if err != nil {
+				return err
+			}
+			return withSmart(args[0], func(ctx context.Context, conn mpd.Conn, ts []mpd.Track) error {
+				if jsonFlag(cmd) {
+					out := make([]trackView, len(ts))
+					for i, t := range ts {
+						out[i] = newTrackView(t)
+					}
+					return writeJSON(out)
+				}
+				var total time.Duration
+				for _, t := range ts {
+					total += t.Duration
+					if tmpl != nil {
+						if err := execTemplate(os.Stdout, tmpl, newTrackView(t)); err != nil {
+							return err
+						}
+						continue
+					}
+					fmt.Printf("%s (%s)\n", songText(t), clock(t.Duration))
+				}
+				if tmpl == nil {
+					fmt.Printf("%d songs, %s\n", len(ts), clock(total))
+				}
+				return nil
+			})
+		},
+	}
+	addFormatFlags(showCmd)
+
+	smartCmd.AddCommand(listCmd, newCmd, showCmd,
+		&cobra.Command{
+			Use:          "rm <name>",
+			Short:        "Delete a smart playlist",
+			Args:         cobra.ExactArgs(1),
+			SilenceUsage: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return smart.Remove(smartDir(), args[0])
+			},
+		},
+		&cobra.Command{
+			Use:          "play <name>",
+			Short:        "Replace the queue with a smart playlist and play it",
+			Args:         cobra.ExactArgs(1),
+			SilenceUsage: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return withSmart(args[0], func(ctx context.Context, conn mpd.Conn, ts []mpd.Track) error {
+					if len(ts) == 0 {
+						return fmt.Errorf("smart playlist %q matches no songs", args[0])
+					}
+					if err := conn.QueueClear(ctx); err != nil {
+						return err
+					}
+					if err := queueTracks(ctx, conn, ts); err != nil {
+						return err
+					}
+					return conn.PlayPos(ctx, 0)
+				})
+			},
+		},
+		&cobra.Command{
+			Use:          "add <name>",
+			Short:        "Append a smart playlist to the queue",
+			Args:         cobra.ExactArgs(1),
+			SilenceUsage: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return withSmart(args[0], func(ctx context.Context, conn mpd.Conn, ts []mpd.Track) error {
+					return queueTracks(ctx, conn, ts)
+				})
+			},
+		},
+		&cobra.Command{
+			Use:          "save <name> [playlist]",
+			Short:        "Write a smart playlist's songs to a stored playlist (default: same name)",
+			Args:         cobra.RangeArgs(1, 2),
+			SilenceUsage: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				dest := args[0]
+				if len(args) == 2 {
+					dest = args[1]
+				}
+				return withSmart(args[0], func(ctx context.Context, conn mpd.Conn, ts []mpd.Track) error {
+					return smart.SavePlaylist(ctx, conn, dest, ts)
+				})
+			},
+		},
+	)
+	rootCmd.AddCommand(smartCmd)
+}
+
+func smartDir() string {
+	return smart.Dir(viper.GetString("config_path"))
+}
+
+// Loads and evaluates the named smart playlist, then hands its songs to fn.
+func withSmart(name string, fn func(ctx context.Context, conn mpd.Conn, ts []mpd.Track) error) error {
+	p, err := smart.Load(smartDir(), name)
+	if err != nil {
+		return err
+	}
+	q, err := p.Compile()
+	if err != nil {
+		return err
+	}
+	return withConn(func(ctx context.Context, conn mpd.Conn) error {
+		ts, err := smart.Evaluate(ctx, conn, q, time.Now())
+		if err != nil {
+			return err
+		}
+		return fn(ctx, conn, ts)
+	})
+}
+
+func queueTracks(ctx context.Context, conn mpd.Conn, ts []mpd.Track) error {
+	for _, t := range ts {
+		if err := conn.QueueAdd(ctx, t.URI); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/AJMerr/gompc/internal/app"
+)
+
+func init() {
+	var width int
+
+	themesCmd := &cobra.Command{
+		Use:   "themes [name...]",
+		Short: "Preview built-in and configured TUI themes",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			user, err := userThemes()
+			if err != nil {
+				return err
+			}
+			names := args
+			if len(names) == 0 {
+				names = app.BuiltinThemeNames()
+				for n := range user {
+					if !slices.Contains(names, n) {
+						names = append(names, n)
+					}
+				}
+				sort.Strings(names)
+			}
+			profile := lipgloss.ColorProfile()
+			for _, n := range names {
+				t, err := app.ResolveTheme(n, user, profile)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("── %s ──\n%s\n\n", n, app.PreviewTheme(t, width))
+			}
+			return nil
+		},
+	}
+	themesCmd.Flags().IntVar(&width, "width", 72, "Preview width")
+
+	rootCmd.AddCommand(themesCmd)
+}
+
+// [themes.<name>] tables from config.toml
+func userThemes() (map[string]app.ThemeSpec, error) {
+	var user map[string]app.ThemeSpec
+	if err := viper.UnmarshalKey("themes", &user); err != nil {
+		return nil, fmt.Errorf("themes: %w", err)
+	}
+	return user, nil
+}
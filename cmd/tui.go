@@ -1,14 +1,22 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/AJMerr/gompc/internal/app"
+	"github.com/AJMerr/gompc/internal/lyrics"
 	"github.com/AJMerr/gompc/internal/mpd"
+	"github.com/AJMerr/gompc/internal/notify"
+	"github.com/AJMerr/gompc/internal/smart"
 )
 
 func init() {
@@ -16,20 +24,134 @@ func init() {
 		Use:   "tui",
 		Short: "Run the TUI music player",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg := mpd.Config{
-				Host:    viper.GetString("mpd.host"),
-				Port:    viper.GetInt("mpd.port"),
-				Timeout: time.Duration(viper.GetInt("mpd.timeout_ms")) * time.Millisecond,
+			cfg := mpdConfig()
+			browse := app.DefaultHierarchies()
+			if viper.IsSet("tui.browse") {
+				browse = nil
+				if err := viper.UnmarshalKey("tui.browse", &browse); err != nil {
+					return fmt.Errorf("tui.browse: %w", err)
+				}
+			}
+			for _, h := range browse {
+				if err := h.Validate(); err != nil {
+					return err
+				}
+			}
+			var cols []app.Column
+			if names := viper.GetStringSlice("tui.columns"); len(names) > 0 {
+				var err error
+				if cols, err = app.ParseColumns(names); err != nil {
+					return fmt.Errorf("tui.columns: %w", err)
+				}
+			}
+			overrides, err := keyOverrides()
+			if err != nil {
+				return err
+			}
+			keys, err := app.NewKeymap(overrides)
+			if err != nil {
+				return err
+			}
+			user, err := userThemes()
+			if err != nil {
+				return err
+			}
+			theme, err := app.ResolveTheme(viper.GetString("tui.theme"), user, lipgloss.ColorProfile())
+			if err != nil {
+				return err
+			}
+			dj, err := autodjConfig()
+			if err != nil {
+				return err
+			}
+			var queries []*smart.Query
+			playlists, err := smart.LoadAll(smartDir())
+			if err != nil {
+				return err
+			}
+			for _, p := range playlists {
+				q, err := p.Compile()
+				if err != nil {
+					return fmt.Errorf("smart playlist %w", err)
+				}
+				queries = append(queries, q)
+			}
+			var historyFile string
+			if viper.GetBool("history.enabled") {
+				historyFile = historyPath()
+			}
+			var notifier *notify.Notifier
+			if viper.GetBool("notify.enabled") {
+				ncfg, err := notifyConfig()
+				if err != nil {
+					return err
+				}
+				notifier = notify.New(ncfg)
 			}
 			deps := app.Deps{
-				Client: mpd.NewClient(),
-				Cfg:    cfg,
+				Client:  mpd.NewClient(),
+				Cfg:     cfg,
+				Browse:  browse,
+				Columns: cols,
+				Keys:    keys,
+				Theme:   theme,
+				Lyrics: lyrics.Finder{
+					MusicDir: expandHome(viper.GetString("mpd.music_dir")),
+					Dir:      expandHome(viper.GetString("lyrics.dir")),
+				},
+				Smart:   queries,
+				AutoDJ:  dj,
+				History: historyFile,
+				Notify:  notifier,
+
+				Profiles: tuiProfiles(),
+				Profile:  viper.GetString("profile"),
+
+				PrevRestart: time.Duration(viper.GetFloat64("tui.prev_restart_secs") * float64(time.Second)),
 			}
 			m := app.New(deps)
-			p := tea.NewProgram(m, tea.WithAltScreen())
-			_, err := p.Run()
+			p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
+			_, err = p.Run()
 			return err
 		},
 	}
+	viper.SetDefault("tui.theme", app.DefaultThemeName)
+	viper.SetDefault("tui.prev_restart_secs", 3)
+	tuiCmd.Flags().String("theme", "", "Theme name (see `gompc themes`)")
+	_ = viper.BindPFlag("tui.theme", tuiCmd.Flags().Lookup("theme"))
+
 	rootCmd.AddCommand(tuiCmd)
 }
+
+// Reads the [keys] table: action = "key" or action = ["key", "g g", ...].
+func keyOverrides() (map[string][]string, error) {
+	raw := viper.GetStringMap("keys")
+	out := make(map[string][]string, len(raw))
+	for action, v := range raw {
+		switch v := v.(type) {
+		case string:
+			out[action] = []string{v}
+		case []any:
+			for _, k := range v {
+				s, ok := k.(string)
+				if !ok {
+					return nil, fmt.Errorf("keys.%s: want strings, got %T", action, k)
+				}
+				out[action] = append(out[action], s)
+			}
+		default:
+			return nil, fmt.Errorf("keys.%s: want a string or list of strings, got %T", action, v)
+		}
+	}
+	return out, nil
+}
+
+// "~/x" -> "$HOME/x" for paths read from config.
+func expandHome(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, p[1:])
+		}
+	}
+	return p
+}
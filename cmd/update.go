@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+)
+
+func updateCmd() *cobra.Command {
+	var rescan, wait bool
+	c := &cobra.Command{
+		Use:   "update [path]",
+		Short: "Update the music database",
+		Long: "Starts a database update of path (default: everything) and prints the\n" +
+			"job id. --rescan also re-reads unchanged files. --wait blocks until the\n" +
+			"job has finished; --timeout then applies to each request, not the wait.",
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := ""
+			if len(args) == 1 {
+				path = args[0]
+			}
+			cfg := mpdConfig()
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			cctx, cancel := context.WithTimeout(ctx, opTimeout(cfg))
+			conn, err := mpd.NewClient().Connect(cctx, cfg)
+			if err != nil {
+				cancel()
+				return err
+			}
+			defer conn.Close()
+
+			start := time.Now()
+			var job int
+			if rescan {
+				job, err = conn.Rescan(cctx, path)
+			} else {
+				job, err = conn.Update(cctx, path)
+			}
+			cancel()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("updating: job %d\n", job)
+			if !wait {
+				return nil
+			}
+			if err := waitUpdate(ctx, conn, opTimeout(cfg), job); err != nil {
+				return err
+			}
+			fmt.Printf("updated: job %d in %s\n", job, time.Since(start).Round(100*time.Millisecond))
+			return nil
+		},
+	}
+	c.Flags().BoolVar(&rescan, "rescan", false, "Re-read all files, not just changed ones")
+	c.Flags().BoolVar(&wait, "wait", false, "Wait for the update to finish")
+	return c
+}
+
+// Idles on database/update events until job is no longer running. MPD
+// numbers jobs in order, so a higher id running means ours is done.
+func waitUpdate(ctx context.Context, conn mpd.Conn, timeout time.Duration, job int) error {
+	for {
+		sctx, cancel := context.WithTimeout(ctx, timeout)
+		st, err := conn.PlayerStatus(sctx)
+		cancel()
+		if err != nil {
+			return err
+		}
+		if st.UpdatingDB == 0 || st.UpdatingDB > job {
+			return nil
+		}
+		if _, err := conn.Idle(ctx, []string{"database", "update"}); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+)
+
+var watchSubsystems = []string{"player", "mixer", "options", "playlist"}
+
+// One line of `gompc watch`. Templates see the status fields directly;
+// State is "disconnected" while the server is away.
+type watchEvent struct {
+	Event   string   `json:"event"` // "status" or "disconnected"
+	Time    string   `json:"time"`
+	Changed []string `json:"changed,omitempty"`
+	Reason  string   `json:"reason,omitempty"`
+	statusView
+}
+
+func init() {
+	watchCmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Print a line whenever the player, volume, options or queue change",
+		Long: "Holds an idle connection to MPD and prints one line per change: the\n" +
+			"current state at start, then after every player, mixer, options or\n" +
+			"playlist event. Reconnects on its own and prints a \"disconnected\"\n" +
+			"record when the server goes away. --format and --json as for status;\n" +
+			"--json writes one object per line.",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tmpl, err := formatFlag(cmd)
+			if err != nil {
+				return err
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			emit := func(ev watchEvent) error {
+				ev.Time = time.Now().Format(time.RFC3339)
+				switch {
+				case jsonFlag(cmd):
+					var b []byte
+					var err error
+					if ev.Event == "disconnected" {
+						b, err = json.Marshal(struct {
+							Event  string `json:"event"`
+							Time   string `json:"time"`
+							Reason string `json:"reason,omitempty"`
+						}{ev.Event, ev.Time, ev.Reason})
+					} else {
+						b, err = json.Marshal(ev)
+					}
+					if err != nil {
+						return err
+					}
+					_, err = os.Stdout.Write(append(b, '\n'))
+					return err
+				case tmpl != nil:
+					return execTemplate(os.Stdout, tmpl, ev)
+				}
+				fmt.Println(watchText(ev))
+				return nil
+			}
+			return watch(ctx, mpdConfig(), emit)
+		},
+	}
+	addFormatFlags(watchCmd)
+	rootCmd.AddCommand(watchCmd)
+}
+
+// Connects, reports, idles; on failure reports once and retries with
+// backoff until ctx is done.
+func watch(ctx context.Context, cfg mpd.Config, emit func(watchEvent) error) error {
+	down := func(err error) error {
+		ev := watchEvent{Event: "disconnected", Reason: err.Error()}
+		ev.State = "disconnected"
+		return emit(ev)
+	}
+	return reconnect(ctx, down, func(connected func()) error {
+		return watchConn(ctx, cfg, connected, emit)
+	})
+}
+
+func watchConn(ctx context.Context, cfg mpd.Config, connected func(), emit func(watchEvent) error) error {
+	dctx, cancel := context.WithTimeout(ctx, max(cfg.Timeout, time.Second))
+	conn, err := mpd.NewClient().Connect(dctx, cfg)
+	cancel()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	connected()
+
+	var changed []string
+	for {
+		qctx, cancel := context.WithTimeout(ctx, max(cfg.Timeout, time.Second))
+		st, err := conn.PlayerStatus(qctx)
+		var cur mpd.Track
+		if err == nil {
+			cur, err = conn.CurrentSong(qctx)
+		}
+		cancel()
+		if err != nil {
+			return err
+		}
+		ev := watchEvent{Event: "status", Changed: changed, statusView: newStatusView(st, cur)}
+		if err := emit(ev); err != nil {
+			return outputError{err}
+		}
+
+		changed, err = conn.Idle(ctx, watchSubsystems)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Default line: "[playing] Artist - Title 1:23/4:56 vol 50%"
+func watchText(ev watchEvent) string {
+	if ev.Event == "disconnected" {
+		return "[disconnected] " + ev.Reason
+	}
+	state := map[string]string{"play": "playing", "pause": "paused", "stop": "stopped"}[ev.State]
+	parts := []string{"[" + state + "]"}
+	if ev.State != "stop" && ev.Current.URI != "" {
+		parts = append(parts, fmt.Sprintf("%s %s/%s", songText(trackOf(ev.Current)), clock(ev.Elapsed), clock(ev.Duration)))
+	}
+	parts = append(parts, volumeText(ev.Volume))
+	return strings.Join(parts, " ")
+}
+
+func trackOf(v trackView) mpd.Track {
+	return mpd.Track{URI: v.URI, Title: v.Title, Artist: v.Artist}
+}
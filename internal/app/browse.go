@@ -0,0 +1,166 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+)
+
+// A Hierarchy is a tag-driven drill-down (e.g. Genre › Artist › Album) that
+// ends in a track list, the same way the Artists tab does.
+type Hierarchy struct {
+	Name   string
+	Levels []string
+}
+
+func DefaultHierarchies() []Hierarchy {
+	return []Hierarchy{
+		{Name: "Genres", Levels: []string{"genre", "artist", "album"}},
+		{Name: "Years", Levels: []string{"decade", "year", "album"}},
+		{Name: "Composers", Levels: []string{"composer", "work"}},
+	}
+}
+
+// Tag keys usable as hierarchy levels
+var browseLevels = []string{"artist", "album", "genre", "composer", "work", "year", "decade"}
+
+func (h Hierarchy) Validate() error {
+	if strings.TrimSpace(h.Name) == "" {
+		return fmt.Errorf("browse: hierarchy needs a name")
+	}
+	if len(h.Levels) == 0 {
+		return fmt.Errorf("browse %q: no levels", h.Name)
+	}
+	for _, l := range h.Levels {
+		ok := false
+		for _, v := range browseLevels {
+			if strings.EqualFold(l, v) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return fmt.Errorf("browse %q: unknown level %q (want one of %s)", h.Name, l, strings.Join(browseLevels, ", "))
+		}
+	}
+	return nil
+}
+
+func tagValue(t mpd.Track, level string) string {
+	switch strings.ToLower(level) {
+	case "artist":
+		return nz(t.Artist, "<unknown>")
+	case "album":
+		return nz(t.Album, "<unknown>")
+	case "genre":
+		return nz(t.Genre, "<unknown>")
+	case "composer":
+		return nz(t.Composer, "<unknown>")
+	case "work":
+		// Fall back to the album so untagged works still group sensibly
+		return nz(t.Work, nz(t.Album, "<unknown>"))
+	case "year":
+		if t.Year > 0 {
+			return strconv.Itoa(t.Year)
+		}
+	case "decade":
+		if t.Year > 0 {
+			return fmt.Sprintf("%ds", t.Year/10*10)
+		}
+	}
+	return "<unknown>"
+}
+
+func matchesPath(t mpd.Track, h Hierarchy, path []string) bool {
+	for i, want := range path {
+		if !strings.EqualFold(tagValue(t, h.Levels[i]), want) {
+			return false
+		}
+	}
+	return true
+}
+
+// Distinct values of the next level below path.
+func browseItems(ts []mpd.Track, h Hierarchy, path []string) []string {
+	if len(path) >= len(h.Levels) {
+		return nil
+	}
+	level := h.Levels[len(path)]
+	set := map[string]struct{}{}
+	for _, t := range ts {
+		if matchesPath(t, h, path) {
+			set[tagValue(t, level)] = struct{}{}
+		}
+	}
+	out := make([]string, 0, len(set))
+	for v := range set {
+		out = append(out, v)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Tracks under a fully selected path, ordered album then disc/track.
+func browseTracks(ts []mpd.Track, h Hierarchy, path []string) []mpd.Track {
+	var out []mpd.Track
+	for _, t := range ts {
+		if matchesPath(t, h, path) {
+			out = append(out, t)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Album != out[j].Album {
+			return out[i].Album < out[j].Album
+		}
+		return trackLess(out[i], out[j])
+	})
+	return out
+}
+
+func (m Model) browseHier() Hierarchy {
+	return m.hier[m.tabs[m.tabIdx].hier]
+}
+
+// Re-derives the list shown for the current browse path.
+func (m Model) refreshBrowse() Model {
+	h := m.browseHier()
+	if len(m.browsePath) < len(h.Levels) {
+		m.browseItems = browseItems(m.allSongs, h, m.browsePath)
+		m.browseTracks = nil
+	} else {
+		m.browseItems = nil
+		m.browseTracks = browseTracks(m.allSongs, h, m.browsePath)
+	}
+	return m
+}
+
+func (m Model) browseCrumb() string {
+	parts := append([]string{m.browseHier().Name}, m.browsePath...)
+	return strings.Join(parts, " › ")
+}
+
+// Folder tab entries: directories first, then songs.
+func (m Model) folderLabels() []string {
+	out := make([]string, 0, len(m.dirs)+len(m.dirTracks))
+	for _, d := range m.dirs {
+		out = append(out, baseNameFromURI(d)+"/")
+	}
+	for _, t := range m.dirTracks {
+		title := t.Title
+		if title == "" {
+			title = baseNameFromURI(t.URI)
+		}
+		out = append(out, title)
+	}
+	return out
+}
+
+func (m Model) folderCrumb() string {
+	if m.dir == "" {
+		return "Folders"
+	}
+	return "Folders › " + strings.ReplaceAll(m.dir, "/", " › ")
+}
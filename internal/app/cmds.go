@@ -2,16 +2,42 @@ package app
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"slices"
 	"time"
 
+	"github.com/AJMerr/gompc/internal/autodj"
+	"github.com/AJMerr/gompc/internal/history"
+	"github.com/AJMerr/gompc/internal/lyrics"
 	"github.com/AJMerr/gompc/internal/mpd"
+	"github.com/AJMerr/gompc/internal/notify"
+	"github.com/AJMerr/gompc/internal/smart"
+	"github.com/AJMerr/gompc/internal/stickers"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 // Dependencies passed into command constructors:
 type Deps struct {
-	Client mpd.Client // your mpd.NewClient()
-	Cfg    mpd.Config // resolved host/port/timeout
+	Client mpd.Client  // your mpd.NewClient()
+	Cfg    mpd.Config  // resolved host/port/timeout
+	Browse []Hierarchy // extra browse tabs (DefaultHierarchies if unset)
+
+	Columns []Column // All tab columns (DefaultColumns if unset)
+	Keys    Keymap   // active bindings (NewKeymap(nil) if unset)
+	Theme   Theme    // resolved theme (Lucy if unset)
+
+	Lyrics  lyrics.Finder    // lyrics file locations
+	Smart   []*smart.Query   // smart playlists for the Smart tab
+	AutoDJ  autodj.Config    // used when auto-DJ is toggled on
+	History string           // listening history file ("" = don't record)
+	Notify  *notify.Notifier // desktop notifications on song change (nil = off)
+
+	Profiles []Profile // servers for the Servers tab (none = no tab)
+	Profile  string    // name of the profile Cfg came from ("" = none)
+
+	// Previous restarts the track when past this point (0 = never)
+	PrevRestart time.Duration
 }
 
 // Connect to MPD and emit ConnectedMsg or ConnectErrMsg.
@@ -21,9 +47,9 @@ func ConnectCmd(d Deps) tea.Cmd {
 		defer cancel()
 		c, err := d.Client.Connect(ctx, d.Cfg)
 		if err != nil {
-			return ConnectionErrMsg{Err: err}
+			return ConnectionErrMsg{Err: err, Cfg: d.Cfg}
 		}
-		return ConnectionMsg{Conn: c}
+		return ConnectionMsg{Conn: c, Cfg: d.Cfg}
 	}
 }
 
@@ -36,7 +62,20 @@ func FetchLibraryCmd(conn mpd.Conn) tea.Cmd {
 		if err != nil {
 			return ErrMsg{Op: "library", Err: err}
 		}
-		return LibLoadedMsg{Tracks: tracks}
+		return LibLoadedMsg{Tracks: tracks, Conn: conn}
+	}
+}
+
+// List one music directory and emit DirLoadedMsg or ErrMsg{Op:"lsinfo"}.
+func LsInfoCmd(conn mpd.Conn, path string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		dirs, tracks, err := conn.LsInfo(ctx, path)
+		if err != nil {
+			return ErrMsg{Op: "lsinfo", Err: err}
+		}
+		return DirLoadedMsg{Path: path, Dirs: dirs, Tracks: tracks, Conn: conn}
 	}
 }
 
@@ -49,11 +88,11 @@ func StatusCmd(conn mpd.Conn) tea.Cmd {
 		if err != nil {
 			return ErrMsg{Op: "status", Err: err}
 		}
-		return StatusMsg{Now: now}
+		return StatusMsg{Now: now, Conn: conn}
 	}
 }
 
-// Send a playback action (play/toggle/next/prev) then re-fetch Status.
+// Send a playback action (play/toggle/next/prev/stop) then re-fetch Status.
 type PlayAction int
 
 const (
@@ -61,11 +100,17 @@ const (
 	ActionTogglePause
 	ActionNext
 	ActionPrev
+	ActionStop
+	ActionPlay // resume, or start from stopped
 )
 
 type PlayRequest struct {
 	Action PlayAction
 	URI    string // used when ActionPlayURI
+
+	// ActionPrev restarts the current track instead when more than this
+	// much of it has played (0 = always go to the previous track).
+	RestartAfter time.Duration
 }
 
 func PlaybackCmd(conn mpd.Conn, req PlayRequest) tea.Cmd {
@@ -82,24 +127,160 @@ func PlaybackCmd(conn mpd.Conn, req PlayRequest) tea.Cmd {
 			if err := conn.TogglePause(ctx); err != nil {
 				return ErrMsg{Op: "pause", Err: err}
 			}
+		case ActionNext:
+			if err := conn.Next(ctx); err != nil {
+				return ErrMsg{Op: "next", Err: err}
+			}
+		case ActionPrev:
+			restart := false
+			if req.RestartAfter > 0 {
+				now, err := conn.Status(ctx)
+				if err != nil {
+					return ErrMsg{Op: "status", Err: err}
+				}
+				restart = now.State != "stop" && now.Elapsed > req.RestartAfter
+			}
+			if restart {
+				if err := conn.SeekCur(ctx, 0); err != nil {
+					return ErrMsg{Op: "seek", Err: err}
+				}
+			} else if err := conn.Prev(ctx); err != nil {
+				return ErrMsg{Op: "previous", Err: err}
+			}
+		case ActionStop:
+			if err := conn.Stop(ctx); err != nil {
+				return ErrMsg{Op: "stop", Err: err}
+			}
+		case ActionPlay:
+			if err := conn.Resume(ctx); err != nil {
+				return ErrMsg{Op: "play", Err: err}
+			}
 		}
 		now, err := conn.Status(ctx)
 		if err != nil {
 			return ErrMsg{Op: "status", Err: err}
 		}
-		return StatusMsg{Now: now}
+		return StatusMsg{Now: now, Conn: conn}
 	}
 }
 
-// Long-poll MPD idle for player/database changes.
+func SeekCmd(conn mpd.Conn, pos time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := conn.SeekCur(ctx, pos); err != nil {
+			return ErrMsg{Op: "seek", Err: err}
+		}
+		now, err := conn.Status(ctx)
+		if err != nil {
+			return ErrMsg{Op: "status", Err: err}
+		}
+		return StatusMsg{Now: now, Conn: conn}
+	}
+}
+
+// Subsystems the TUI idles on
+var idleSubs = []string{"player", "mixer", "options", "database", "update", "output", "sticker", "playlist"}
+
+// Opens the second connection that IdleCmd blocks on, so the main one
+// stays free for commands.
+func IdleConnectCmd(d Deps) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), d.Cfg.Timeout)
+		defer cancel()
+		c, err := d.Client.Connect(ctx, d.Cfg)
+		if err != nil {
+			return ErrMsg{Op: "idle", Err: err}
+		}
+		return IdleConnMsg{Conn: c, Cfg: d.Cfg}
+	}
+}
+
+// Long-poll MPD idle on the idle connection; re-issue from Update.
 func IdleCmd(conn mpd.Conn, subs []string) tea.Cmd {
 	return func() tea.Msg {
-		// TODO: evs, err := conn.Idle(ctx, subs)
-		// return IdleEventMsg{Subs: evs} or ErrMsg{Op:"idle"}
-		return ErrMsg{Op: "idle", Err: nil} // placeholder
+		evs, err := conn.Idle(context.Background(), subs)
+		if err != nil {
+			return ErrMsg{Op: "idle", Err: err}
+		}
+		return IdleEventMsg{Subs: evs, Conn: conn}
+	}
+}
+
+func OutputsCmd(conn mpd.Conn) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		outs, err := conn.Outputs(ctx)
+		if err != nil {
+			return ErrMsg{Op: "outputs", Err: err}
+		}
+		return OutputsMsg{Outputs: outs, Conn: conn}
+	}
+}
+
+func PartitionsCmd(conn mpd.Conn) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		names, err := conn.ListPartitions(ctx)
+		if err != nil {
+			return ErrMsg{Op: "partitions", Err: err}
+		}
+		return PartitionsMsg{Names: names, Conn: conn}
+	}
+}
+
+// Moves the main connection to partition name.
+func PartitionCmd(conn mpd.Conn, name string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := conn.Partition(ctx, name); err != nil {
+			return ErrMsg{Op: "partition", Err: err}
+		}
+		return PartitionMsg{Name: name, Conn: conn}
 	}
 }
 
+// Flips an output on or off and re-lists them.
+func ToggleOutputCmd(conn mpd.Conn, id int) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := conn.ToggleOutput(ctx, id); err != nil {
+			return ErrMsg{Op: "toggle output", Err: err}
+		}
+		outs, err := conn.Outputs(ctx)
+		if err != nil {
+			return ErrMsg{Op: "outputs", Err: err}
+		}
+		return OutputsMsg{Outputs: outs, Conn: conn}
+	}
+}
+
+// Starts a full database update and refreshes status so the header
+// shows the job.
+func UpdateDBCmd(conn mpd.Conn) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if _, err := conn.Update(ctx, ""); err != nil {
+			return ErrMsg{Op: "update", Err: err}
+		}
+		now, err := conn.Status(ctx)
+		if err != nil {
+			return ErrMsg{Op: "status", Err: err}
+		}
+		return StatusMsg{Now: now, Conn: conn}
+	}
+}
+
+// Drops a partial key sequence nobody finished.
+func KeySeqTimeoutCmd(press int) tea.Cmd {
+	return tea.Tick(keySeqTimeout, func(time.Time) tea.Msg { return KeySeqTimeoutMsg{Press: press} })
+}
+
 // UI tick for animating elapsed time; re-schedule from Update.
 func TickCmd(interval time.Duration) tea.Cmd {
 	return func() tea.Msg {
@@ -147,7 +328,7 @@ func EnqueueAndPlayCmd(conn mpd.Conn, uris []string, start int) tea.Cmd {
 		if err != nil {
 			return ErrMsg{Op: "status", Err: err}
 		}
-		return StatusMsg{Now: now}
+		return StatusMsg{Now: now, Conn: conn}
 	}
 }
 
@@ -166,3 +347,160 @@ func EnqueueAlbumFromCursor(conn mpd.Conn, tracks []mpd.Track, start int) tea.Cm
 	}
 	return EnqueueAndPlayCmd(conn, uris, start)
 }
+
+// Append uris to the end of the queue without touching playback.
+func QueueAppendCmd(conn mpd.Conn, uris []string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		for _, uri := range uris {
+			if err := conn.QueueAdd(ctx, uri); err != nil {
+				return ErrMsg{Op: "add", Err: err}
+			}
+		}
+		return NoticeMsg{Text: fmt.Sprintf("queued %d tracks", len(uris))}
+	}
+}
+
+// Append uris to a stored playlist (created if missing).
+func PlaylistAddCmd(conn mpd.Conn, name string, uris []string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		for _, uri := range uris {
+			if err := conn.PlaylistAdd(ctx, name, uri); err != nil {
+				return ErrMsg{Op: "playlistadd", Err: err}
+			}
+		}
+		return NoticeMsg{Text: fmt.Sprintf("added %d tracks to %q", len(uris), name)}
+	}
+}
+
+// Set the rating sticker (0-5 stars, 0 removes it) on each uri.
+func RateCmd(conn mpd.Conn, uris []string, stars int) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		var changes []stickers.Change
+		for _, uri := range uris {
+			c, err := stickers.SetRating(ctx, conn, uri, stars)
+			if err != nil {
+				return ErrMsg{Op: "sticker", Err: err}
+			}
+			changes = append(changes, c)
+		}
+		text := fmt.Sprintf("rated %d tracks %d★", len(uris), stars)
+		if len(uris) == 1 {
+			text = fmt.Sprintf("rated %d★", stars)
+		}
+		return StickerChangeMsg{Changes: changes, Notice: text, Conn: conn}
+	}
+}
+
+// Set or clear the favourite sticker on each uri.
+func FavouriteCmd(conn mpd.Conn, uris []string, on bool) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		var changes []stickers.Change
+		for _, uri := range uris {
+			c, err := stickers.SetFavourite(ctx, conn, uri, on)
+			if err != nil {
+				return ErrMsg{Op: "sticker", Err: err}
+			}
+			changes = append(changes, c)
+		}
+		verb := "added"
+		if !on {
+			verb = "removed"
+		}
+		return StickerChangeMsg{Changes: changes, Notice: fmt.Sprintf("%s %d favourites", verb, len(uris)), Conn: conn}
+	}
+}
+
+// Load the library's stickers. Servers without a sticker database answer
+// with an error, which just leaves the sticker columns empty.
+func LoadStickersCmd(conn mpd.Conn) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		db, err := stickers.Load(ctx, conn)
+		if err != nil {
+			return StickersMsg{Conn: conn}
+		}
+		return StickersMsg{DB: db, Conn: conn}
+	}
+}
+
+// Count a finished play of uri.
+func RecordPlayCmd(conn mpd.Conn, uri string, at time.Time) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		changes, err := stickers.RecordPlay(ctx, conn, uri, at)
+		if err != nil {
+			return ErrMsg{Op: "playcount", Err: err}
+		}
+		return StickerChangeMsg{Changes: changes, Conn: conn}
+	}
+}
+
+// Read the listening history for the History tab.
+func HistoryCmd(path string) tea.Cmd {
+	return func() tea.Msg {
+		entries, err := history.Read(path)
+		if err != nil {
+			return ErrMsg{Op: "history", Err: err}
+		}
+		slices.Reverse(entries)
+		return HistoryMsg{Entries: entries}
+	}
+}
+
+// Append a finished or skipped play to the history file.
+func AppendHistoryCmd(path string, e history.Entry) tea.Cmd {
+	return func() tea.Msg {
+		if err := history.Append(path, e); err != nil {
+			return ErrMsg{Op: "history", Err: err}
+		}
+		return HistoryAddMsg{Entry: e}
+	}
+}
+
+// Let the notifier see a new snapshot; it pops up on song changes.
+func NotifyCmd(n *notify.Notifier, conn mpd.Conn, np mpd.NowPlaying) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := n.Observe(ctx, conn, np); err != nil {
+			return ErrMsg{Op: "notify", Err: err}
+		}
+		return nil
+	}
+}
+
+// Replace the stored playlist name with ts.
+func SmartSaveCmd(conn mpd.Conn, name string, ts []mpd.Track) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := smart.SavePlaylist(ctx, conn, name, ts); err != nil {
+			return ErrMsg{Op: "save playlist", Err: err}
+		}
+		return NoticeMsg{Text: fmt.Sprintf("saved %d tracks to %q", len(ts), name)}
+	}
+}
+
+// Top up the queue from lib. db is copied; Update keeps changing it.
+func AutoDJCmd(conn mpd.Conn, dj *autodj.DJ, lib []mpd.Track, db stickers.DB) tea.Cmd {
+	db = maps.Clone(db)
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		added, err := dj.TopUp(ctx, conn, lib, db)
+		if err != nil {
+			return ErrMsg{Op: "autodj", Err: err}
+		}
+		return AutoDJMsg{Added: added, Conn: conn}
+	}
+}
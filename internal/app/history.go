@@ -0,0 +1,43 @@
+package app
+
+import (
+	"time"
+
+	"github.com/AJMerr/gompc/internal/history"
+	"github.com/AJMerr/gompc/internal/mpd"
+)
+
+// The library track behind a history entry, or one rebuilt from the entry
+// when the song has left the library.
+func (m Model) historyTrack(e history.Entry) mpd.Track {
+	for _, t := range m.libSongs {
+		if t.URI == e.URI {
+			return t
+		}
+	}
+	return mpd.Track{URI: e.URI, Title: e.Title, Artist: e.Artist, Album: e.Album, Duration: e.Duration()}
+}
+
+func historyViewStyled(m Model) string {
+	s := m.styles
+	crumb := s.Breadcrumb.Render("History › Enter plays • ↷ skipped • gompc stats for totals") + "\n"
+	if m.deps.History == "" {
+		return crumb + s.ListRowDim.Render("(history is off; set history.enabled)")
+	}
+	today := time.Now().Format("2006-01-02")
+	labels := make([]string, len(m.history))
+	for i, e := range m.history {
+		ended := e.Ended.Local()
+		when := ended.Format("Jan 02 15:04")
+		if ended.Format("2006-01-02") == today {
+			when = "today  " + ended.Format("15:04")
+		}
+		mark := "  "
+		if e.Skipped {
+			mark = "↷ "
+		}
+		t := mpd.Track{URI: e.URI, Title: e.Title}
+		labels[i] = s.ListRowDim.Render(when+" ") + mark + nz(e.Artist, "<unknown>") + " — " + cellValue(t, ColTitle)
+	}
+	return crumb + plainListStyled(m, labels, "(nothing played yet)")
+}
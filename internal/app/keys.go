@@ -0,0 +1,423 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Named actions that keys are bound to
+type Action string
+
+const (
+	ActQuit         Action = "quit"
+	ActUp           Action = "up"
+	ActDown         Action = "down"
+	ActTop          Action = "top"
+	ActBottom       Action = "bottom"
+	ActNextTab      Action = "next_tab"
+	ActPrevTab      Action = "prev_tab"
+	ActEnter        Action = "enter"
+	ActBack         Action = "back"
+	ActTogglePause  Action = "toggle_pause"
+	ActPlay         Action = "play"
+	ActStop         Action = "stop"
+	ActNext         Action = "next"
+	ActPrev         Action = "prev"
+	ActSortCycle    Action = "sort"
+	ActSortReverse  Action = "sort_reverse"
+	ActLyrics       Action = "lyrics"
+	ActUpdateDB     Action = "update_db"
+	ActSelect       Action = "select"
+	ActMark         Action = "mark"
+	ActMarkRange    Action = "mark_range"
+	ActInvert       Action = "invert"
+	ActMarkAll      Action = "mark_all"
+	ActMarkMatch    Action = "mark_match"
+	ActClearMarks   Action = "clear_marks"
+	ActAppend       Action = "append"
+	ActPlayMarked   Action = "play_marked"
+	ActAddPlaylist  Action = "add_to_playlist"
+	ActRate0        Action = "rate_0"
+	ActRate1        Action = "rate_1"
+	ActRate2        Action = "rate_2"
+	ActRate3        Action = "rate_3"
+	ActRate4        Action = "rate_4"
+	ActRate5        Action = "rate_5"
+	ActFavourite    Action = "favourite"
+	ActSaveSmart    Action = "save_smart"
+	ActAutoDJ       Action = "autodj"
+	ActServers      Action = "servers"
+	ActCancelSelect Action = "cancel"
+)
+
+type actionSpec struct {
+	keys       []string
+	selectMode bool // only active in select mode (and shadows normal keys there)
+}
+
+// Default bindings. A binding is a space-separated key sequence ("g g");
+// a single token of plain letters ("gg") is read as a sequence too. A
+// pending sequence is dropped after keySeqTimeout.
+var defaultKeys = map[Action]actionSpec{
+	ActQuit:        {keys: []string{"q", "ctrl+c"}},
+	ActUp:          {keys: []string{"up", "k"}},
+	ActDown:        {keys: []string{"down", "j"}},
+	ActTop:         {keys: []string{"gg", "home"}},
+	ActBottom:      {keys: []string{"G", "end"}},
+	ActNextTab:     {keys: []string{"tab"}},
+	ActPrevTab:     {keys: []string{"shift+tab"}},
+	ActEnter:       {keys: []string{"enter"}},
+	ActBack:        {keys: []string{"backspace", "h"}},
+	ActTogglePause: {keys: []string{"space"}},
+	ActPlay:        {keys: []string{"P"}},
+	ActStop:        {keys: []string{"s"}},
+	ActNext:        {keys: []string{"n"}},
+	ActPrev:        {keys: []string{"p"}},
+	ActSortCycle:   {keys: []string{"o"}},
+	ActSortReverse: {keys: []string{"O"}},
+	ActLyrics:      {keys: []string{"y"}},
+	ActUpdateDB:    {keys: []string{"U"}},
+	ActSelect:      {keys: []string{"v"}},
+	ActRate0:       {keys: []string{"0"}},
+	ActRate1:       {keys: []string{"1"}},
+	ActRate2:       {keys: []string{"2"}},
+	ActRate3:       {keys: []string{"3"}},
+	ActRate4:       {keys: []string{"4"}},
+	ActRate5:       {keys: []string{"5"}},
+	ActFavourite:   {keys: []string{"f"}},
+	ActSaveSmart:   {keys: []string{"S"}},
+	ActAutoDJ:      {keys: []string{"A"}},
+	ActServers:     {keys: []string{"C"}},
+
+	ActMark:         {keys: []string{"space"}, selectMode: true},
+	ActMarkRange:    {keys: []string{"V"}, selectMode: true},
+	ActInvert:       {keys: []string{"i"}, selectMode: true},
+	ActMarkAll:      {keys: []string{"*"}, selectMode: true},
+	ActMarkMatch:    {keys: []string{"/"}, selectMode: true},
+	ActClearMarks:   {keys: []string{"u"}, selectMode: true},
+	ActAppend:       {keys: []string{"a"}, selectMode: true},
+	ActPlayMarked:   {keys: []string{"enter"}, selectMode: true},
+	ActAddPlaylist:  {keys: []string{"L"}, selectMode: true},
+	ActCancelSelect: {keys: []string{"esc", "v"}, selectMode: true},
+}
+
+// Multi-rune names that are a single key, as reported by tea.KeyMsg.String()
+var namedKeys = map[string]bool{
+	"up": true, "down": true, "left": true, "right": true,
+	"enter": true, "tab": true, "backspace": true, "esc": true, "space": true,
+	"home": true, "end": true, "pgup": true, "pgdown": true,
+	"delete": true, "insert": true,
+}
+
+const seqSep = "\x1f"
+
+// How long a partial sequence waits for its next key
+const keySeqTimeout = time.Second
+
+type Keymap struct {
+	bindings map[Action][]string
+	normal   map[string]Action // joined key sequence -> action
+	sel      map[string]Action // select mode: select actions over normal ones
+}
+
+// Builds the active keymap from the defaults plus user overrides
+// (action -> key sequences), rejecting unknown actions and conflicts.
+func NewKeymap(overrides map[string][]string) (Keymap, error) {
+	km := Keymap{
+		bindings: map[Action][]string{},
+		normal:   map[string]Action{},
+		sel:      map[string]Action{},
+	}
+	for a, sp := range defaultKeys {
+		km.bindings[a] = sp.keys
+	}
+	for name, keys := range overrides {
+		a := Action(strings.ToLower(name))
+		if _, ok := defaultKeys[a]; !ok {
+			return Keymap{}, fmt.Errorf("keys: unknown action %q", name)
+		}
+		km.bindings[a] = keys
+	}
+
+	var errs []string
+	add := func(dst map[string]Action, seq string, a Action) {
+		if prev, ok := dst[seq]; ok && prev != a {
+			errs = append(errs, fmt.Sprintf("%q is bound to both %s and %s", displaySeq(seq), prev, a))
+			return
+		}
+		dst[seq] = a
+	}
+	// deterministic error order
+	actions := make([]Action, 0, len(km.bindings))
+	for a := range km.bindings {
+		actions = append(actions, a)
+	}
+	sort.Slice(actions, func(i, j int) bool { return actions[i] < actions[j] })
+
+	for _, a := range actions {
+		if defaultKeys[a].selectMode {
+			continue
+		}
+		for _, k := range km.bindings[a] {
+			seq, err := parseKeySeq(k)
+			if err != nil {
+				return Keymap{}, fmt.Errorf("keys.%s: %w", a, err)
+			}
+			add(km.normal, seq, a)
+		}
+	}
+	for _, a := range actions {
+		if !defaultKeys[a].selectMode {
+			continue
+		}
+		for _, k := range km.bindings[a] {
+			seq, err := parseKeySeq(k)
+			if err != nil {
+				return Keymap{}, fmt.Errorf("keys.%s: %w", a, err)
+			}
+			add(km.sel, seq, a)
+		}
+	}
+	// select mode falls back to normal bindings it doesn't shadow
+	for seq, a := range km.normal {
+		if _, ok := km.sel[seq]; !ok {
+			km.sel[seq] = a
+		}
+	}
+	errs = append(errs, prefixConflicts(km.normal)...)
+	errs = append(errs, prefixConflicts(km.sel)...)
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return Keymap{}, fmt.Errorf("key binding conflicts:\n  %s", strings.Join(dedup(errs), "\n  "))
+	}
+	return km, nil
+}
+
+// A sequence that is a prefix of another could never complete.
+func prefixConflicts(m map[string]Action) []string {
+	var out []string
+	for a, aa := range m {
+		for b, ba := range m {
+			if a != b && strings.HasPrefix(b, a+seqSep) {
+				out = append(out, fmt.Sprintf("%q (%s) shadows %q (%s)", displaySeq(a), aa, displaySeq(b), ba))
+			}
+		}
+	}
+	return out
+}
+
+func dedup(ss []string) []string {
+	out := ss[:0]
+	for i, s := range ss {
+		if i == 0 || s != ss[i-1] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Normalizes a binding into tea key strings joined by seqSep. Names and
+// modifiers are case-insensitive ("Space", "Ctrl+C"); single characters
+// are not ("G" is shift+g).
+func parseKeySeq(s string) (string, error) {
+	var keys []string
+	for _, tok := range strings.Fields(s) {
+		switch {
+		case isKeyName(tok):
+			keys = append(keys, keyName(tok))
+		case strings.Contains(tok, "+") && len(tok) > 1:
+			k, err := parseModKey(tok)
+			if err != nil {
+				return "", err
+			}
+			keys = append(keys, k)
+		case len(tok) > 1 && (tok[0] == 'f' || tok[0] == 'F') && strings.Trim(tok[1:], "0123456789") == "":
+			return "", fmt.Errorf("unknown key %q (function keys are f1-f20)", tok)
+		default:
+			for _, r := range tok {
+				keys = append(keys, string(r))
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return "", fmt.Errorf("empty key binding")
+	}
+	return strings.Join(keys, seqSep), nil
+}
+
+// A named key or function key, in any case.
+func isKeyName(tok string) bool {
+	low := strings.ToLower(tok)
+	if namedKeys[low] {
+		return true
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(low, "f"))
+	return len(low) > 1 && low[0] == 'f' && err == nil && n >= 1 && n <= 20
+}
+
+func keyName(tok string) string {
+	if strings.EqualFold(tok, "space") {
+		return " "
+	}
+	return strings.ToLower(tok)
+}
+
+// "Ctrl+Shift+Up" -> "ctrl+shift+up". The key after the modifiers is a
+// name or one character; ctrl+ letters are lowercase, as tea reports them.
+func parseModKey(tok string) (string, error) {
+	i := strings.LastIndex(tok[:len(tok)-1], "+") // "ctrl++" binds '+'
+	mods, key := strings.ToLower(tok[:i]), tok[i+1:]
+	for _, mod := range strings.Split(mods, "+") {
+		if mod != "ctrl" && mod != "alt" && mod != "shift" {
+			return "", fmt.Errorf("unknown modifier %q in %q", mod, tok)
+		}
+	}
+	switch {
+	case mods == "ctrl" && strings.EqualFold(key, "space"):
+		return "ctrl+@", nil // what terminals send
+	case isKeyName(key):
+		key = keyName(key)
+	case utf8.RuneCountInString(key) != 1:
+		return "", fmt.Errorf("unknown key %q in %q", key, tok)
+	case strings.Contains(mods, "ctrl"):
+		key = strings.ToLower(key)
+	}
+	return mods + "+" + key, nil
+}
+
+// Feeds one key press; returns the matched action, or the pending prefix
+// when the press may start a longer sequence.
+func (km Keymap) resolve(pending []string, key string, selecting bool) (Action, []string) {
+	table := km.normal
+	if selecting {
+		table = km.sel
+	}
+	seq := append(append([]string(nil), pending...), key)
+	joined := strings.Join(seq, seqSep)
+	if a, ok := table[joined]; ok {
+		return a, nil
+	}
+	for s := range table {
+		if strings.HasPrefix(s, joined+seqSep) {
+			return "", seq
+		}
+	}
+	if len(pending) > 0 {
+		return km.resolve(nil, key, selecting)
+	}
+	return "", nil
+}
+
+func displaySeq(seq string) string {
+	keys := strings.Split(seq, seqSep)
+	for i, k := range keys {
+		keys[i] = displayKey(k)
+	}
+	// "g g" reads better as "gg"
+	joined := strings.Join(keys, " ")
+	if len(keys) > 1 && len(joined) == 2*len(keys)-1 {
+		return strings.Join(keys, "")
+	}
+	return joined
+}
+
+func displayKey(k string) string {
+	switch k {
+	case "up":
+		return "↑"
+	case "down":
+		return "↓"
+	case "left":
+		return "←"
+	case "right":
+		return "→"
+	case " ":
+		return "Space"
+	case "enter", "tab", "backspace", "esc", "home", "end":
+		return strings.ToUpper(k[:1]) + k[1:]
+	case "shift+tab":
+		return "Shift+Tab"
+	}
+	return k
+}
+
+// Keys for an action as shown in help, e.g. "↑/k".
+func (km Keymap) label(a Action) string {
+	var parts []string
+	for _, k := range km.bindings[a] {
+		if seq, err := parseKeySeq(k); err == nil {
+			parts = append(parts, displaySeq(seq))
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+type helpItem struct {
+	actions []Action
+	desc    string
+	sep     string // between the actions' keys; default " "
+}
+
+var normalHelp = []helpItem{
+	{actions: []Action{ActUp, ActDown}, desc: "move"},
+	{actions: []Action{ActEnter}, desc: "play"},
+	{actions: []Action{ActTogglePause}, desc: "pause"},
+	{actions: []Action{ActNext, ActPrev}, desc: "next/prev", sep: "/"},
+	{actions: []Action{ActStop}, desc: "stop"},
+	{actions: []Action{ActNextTab}, desc: "switch"},
+	{actions: []Action{ActSortCycle, ActSortReverse}, desc: "sort", sep: "/"},
+	{actions: []Action{ActSelect}, desc: "select"},
+	{actions: []Action{ActRate0, ActRate5}, desc: "rate", sep: "-"},
+	{actions: []Action{ActFavourite}, desc: "fav"},
+	{actions: []Action{ActLyrics}, desc: "lyrics"},
+	{actions: []Action{ActUpdateDB}, desc: "update db"},
+	{actions: []Action{ActAutoDJ}, desc: "auto-dj"},
+	{actions: []Action{ActServers}, desc: "servers"},
+	{actions: []Action{ActBack}, desc: "up"},
+	{actions: []Action{ActQuit}, desc: "quit"},
+}
+
+var selectHelp = []helpItem{
+	{actions: []Action{ActMark}, desc: "mark"},
+	{actions: []Action{ActMarkRange}, desc: "range"},
+	{actions: []Action{ActInvert}, desc: "invert"},
+	{actions: []Action{ActMarkAll}, desc: "all"},
+	{actions: []Action{ActMarkMatch}, desc: "match"},
+	{actions: []Action{ActClearMarks}, desc: "clear"},
+	{actions: []Action{ActAppend}, desc: "append"},
+	{actions: []Action{ActPlayMarked}, desc: "play"},
+	{actions: []Action{ActAddPlaylist}, desc: "playlist"},
+	{actions: []Action{ActRate0, ActRate5}, desc: "rate", sep: "-"},
+	{actions: []Action{ActFavourite}, desc: "fav"},
+	{actions: []Action{ActCancelSelect}, desc: "done"},
+}
+
+// Footer help built from the active bindings; unbound actions are skipped.
+func (km Keymap) help(selecting bool) string {
+	items := normalHelp
+	if selecting {
+		items = selectHelp
+	}
+	var out []string
+	for _, it := range items {
+		var keys []string
+		for _, a := range it.actions {
+			if l := km.label(a); l != "" {
+				keys = append(keys, l)
+			}
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		sep := it.sep
+		if sep == "" {
+			sep = " "
+		}
+		out = append(out, strings.Join(keys, sep)+" "+it.desc)
+	}
+	return strings.Join(out, " • ")
+}
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/AJMerr/gompc/internal/lyrics"
+	"github.com/AJMerr/gompc/internal/mpd"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Finds lyrics for the playing song: sidecar file, then embedded tags
+// (readcomments), then the lyrics dir.
+func LyricsCmd(conn mpd.Conn, f lyrics.Finder, now mpd.NowPlaying) tea.Cmd {
+	return func() tea.Msg {
+		if l, ok := f.Sidecar(now.URI); ok {
+			return LyricsMsg{URI: now.URI, Lyrics: l}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		// not every file type supports comments; that's not an error here
+		if c, err := conn.ReadComments(ctx, now.URI); err == nil {
+			if l, ok := lyrics.FromComments(c); ok {
+				return LyricsMsg{URI: now.URI, Lyrics: l}
+			}
+		}
+		l, _ := f.Collection(now.URI, now.Artist, now.Title)
+		return LyricsMsg{URI: now.URI, Lyrics: l}
+	}
+}
+
+// Refetches lyrics when the playing song changes.
+func (m Model) syncLyrics() (Model, tea.Cmd) {
+	if m.now.URI == m.lyricsURI {
+		return m, nil
+	}
+	m.lyricsURI = m.now.URI
+	m.lyrics = lyrics.Lyrics{}
+	m.lyricsLoading = m.now.URI != "" && m.conn != nil
+	if !m.lyricsLoading {
+		return m, nil
+	}
+	if m.tab == TabLyrics {
+		m.cursor = 0
+	}
+	return m, LyricsCmd(m.conn, m.deps.Lyrics, m.now)
+}
+
+// Row the lyrics window centres on: the sung line when synced, else the cursor.
+func (m Model) lyricsFocus() int {
+	if m.lyrics.Synced {
+		return max(0, m.lyrics.Current(m.now.Elapsed))
+	}
+	return m.cursor
+}
+
+func (m Model) lyricsCrumb() string {
+	if m.now.URI == "" {
+		return "Lyrics"
+	}
+	title := nz(m.now.Title, baseNameFromURI(m.now.URI))
+	crumb := "Lyrics › " + nz(m.now.Artist, "<unknown>") + " — " + title
+	if m.lyrics.Source != "" {
+		crumb += " (" + m.lyrics.Source + ")"
+	}
+	return crumb
+}
+
+func lyricsViewStyled(m Model) string {
+	s := m.styles
+	var b strings.Builder
+	b.WriteString(s.Breadcrumb.Render(m.lyricsCrumb()) + "\n")
+
+	switch {
+	case m.now.URI == "":
+		return b.String() + s.ListRowDim.Render("(nothing playing)")
+	case m.lyricsLoading:
+		return b.String() + s.ListRowDim.Render("(looking for lyrics…)")
+	case m.lyrics.Empty():
+		return b.String() + s.ListRowDim.Render("(no lyrics found)")
+	}
+
+	pfw, _ := s.Panel.GetFrameSize()
+	cw := max(20, m.width-pfw)
+	rowPad := lipgloss.NewStyle().Width(cw)
+	cur := m.lyrics.Current(m.now.Elapsed)
+	start, end := windowAroundCursor(m.lyricsFocus(), m.maxRowsForList(), len(m.lyrics.Lines))
+	for i := start; i < end; i++ {
+		text := m.lyrics.Lines[i].Text
+		var line string
+		switch {
+		case !m.lyrics.Synced:
+			line = s.ListRow.Render(fitTo(cw, m.gutter(i)+text))
+		case i == cur:
+			line = s.ListRow.Bold(true).Render(fitTo(cw, s.Cursor.Render("▍")+" "+text))
+		case i < cur:
+			line = s.ListRowDim.Render(fitTo(cw, "  "+text))
+		default:
+			line = s.ListRow.Render(fitTo(cw, "  "+text))
+		}
+		b.WriteString(rowPad.Render(line) + "\n")
+	}
+	return b.String()
+}
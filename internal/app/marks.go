@@ -0,0 +1,216 @@
+package app
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+)
+
+// Marks are kept per track URI, in the order they were made; marking an
+// artist, album or folder row marks every track under it.
+type markSet struct {
+	order map[string]int // URI -> when it was marked
+	next  int
+}
+
+func newMarkSet() *markSet { return &markSet{order: map[string]int{}} }
+
+func (ms *markSet) len() int { return len(ms.order) }
+
+func (ms *markSet) has(uri string) bool {
+	_, ok := ms.order[uri]
+	return ok
+}
+
+func (ms *markSet) add(uri string) {
+	if !ms.has(uri) {
+		ms.order[uri] = ms.next
+		ms.next++
+	}
+}
+
+func (ms *markSet) remove(uri string) { delete(ms.order, uri) }
+
+// Marked URIs in the order they were marked.
+func (ms *markSet) uris() []string {
+	out := make([]string, 0, len(ms.order))
+	for uri := range ms.order {
+		out = append(out, uri)
+	}
+	sort.Slice(out, func(i, j int) bool { return ms.order[out[i]] < ms.order[out[j]] })
+	return out
+}
+
+// Tracks represented by row i of the active list.
+func (m Model) rowTracks(i int) []mpd.Track {
+	if i < 0 || i >= m.listLen() {
+		return nil
+	}
+	switch m.tab {
+	case TabAll:
+		return []mpd.Track{m.allSongs[i]}
+	case TabArtists:
+		switch m.level {
+		case LevelArtist:
+			var out []mpd.Track
+			for _, al := range m.index.AlbumsByArtist[m.artists[i]] {
+				out = append(out, m.index.TracksByArtistAlbum[keyAA(m.artists[i], al)]...)
+			}
+			return out
+		case LevelAlbum:
+			return m.index.TracksByArtistAlbum[keyAA(m.selectArtist, m.albums[i])]
+		case LevelTrack:
+			return []mpd.Track{m.tracks[i]}
+		}
+	case TabBrowse:
+		if i < len(m.browseItems) {
+			path := append(append([]string(nil), m.browsePath...), m.browseItems[i])
+			return browseTracks(m.allSongs, m.browseHier(), path)
+		}
+		return []mpd.Track{m.browseTracks[i-len(m.browseItems)]}
+	case TabFolders:
+		if i < len(m.dirs) {
+			var out []mpd.Track
+			prefix := m.dirs[i] + "/"
+			for _, t := range m.libSongs {
+				if strings.HasPrefix(t.URI, prefix) {
+					out = append(out, t)
+				}
+			}
+			return out
+		}
+		return []mpd.Track{m.dirTracks[i-len(m.dirs)]}
+	case TabSmart:
+		return m.smartSongs[i]
+	case TabHistory:
+		return []mpd.Track{m.historyTrack(m.history[i])}
+	}
+	return nil
+}
+
+// Searchable text of row i.
+func (m Model) rowText(i int) string {
+	switch m.tab {
+	case TabAll:
+		t := m.allSongs[i]
+		return strings.Join([]string{t.Artist, cellValue(t, ColTitle), t.Album, t.Genre}, " ")
+	case TabArtists:
+		switch m.level {
+		case LevelArtist:
+			return m.artists[i]
+		case LevelAlbum:
+			return m.albums[i]
+		case LevelTrack:
+			return m.tracks[i].Artist + " " + cellValue(m.tracks[i], ColTitle)
+		}
+	case TabBrowse:
+		if i < len(m.browseItems) {
+			return m.browseItems[i]
+		}
+		t := m.browseTracks[i-len(m.browseItems)]
+		return t.Artist + " " + cellValue(t, ColTitle)
+	case TabFolders:
+		return m.folderLabels()[i]
+	case TabSmart:
+		return m.smart[i].Source.Name
+	case TabHistory:
+		e := m.history[i]
+		return e.Artist + " " + e.Title + " " + e.Album
+	}
+	return ""
+}
+
+func (m Model) rowMarked(i int) bool {
+	if m.marks.len() == 0 {
+		return false
+	}
+	ts := m.rowTracks(i)
+	for _, t := range ts {
+		if !m.marks.has(t.URI) {
+			return false
+		}
+	}
+	return len(ts) > 0
+}
+
+func (m Model) setRowMark(i int, on bool) {
+	for _, t := range m.rowTracks(i) {
+		if on {
+			m.marks.add(t.URI)
+		} else {
+			m.marks.remove(t.URI)
+		}
+	}
+}
+
+func (m Model) toggleMark(i int) {
+	m.setRowMark(i, !m.rowMarked(i))
+}
+
+// Marks every row between the range anchor and the cursor.
+func (m Model) markRange() {
+	lo, hi := m.anchor, m.cursor
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	for i := lo; i <= hi; i++ {
+		m.setRowMark(i, true)
+	}
+}
+
+func (m Model) invertMarks() {
+	for i := range m.listLen() {
+		m.toggleMark(i)
+	}
+}
+
+func (m Model) markAll() {
+	for i := range m.listLen() {
+		m.setRowMark(i, true)
+	}
+}
+
+// Marks rows whose text contains q (case-insensitive); returns the count.
+func (m Model) markMatching(q string) int {
+	q = strings.ToLower(strings.TrimSpace(q))
+	if q == "" {
+		return 0
+	}
+	n := 0
+	for i := range m.listLen() {
+		if strings.Contains(strings.ToLower(m.rowText(i)), q) {
+			m.setRowMark(i, true)
+			n++
+		}
+	}
+	return n
+}
+
+// Marked tracks in the order they were marked, which is row order for
+// ranges and mark-all. Songs gone from the library (History rows) stay in.
+func (m Model) markedURIs() []string {
+	return m.marks.uris()
+}
+
+// Leaves select mode and drops any marks.
+func (m Model) clearSelection() Model {
+	m.selecting = false
+	m.anchor = -1
+	m.marks = newMarkSet()
+	return m
+}
+
+// Cursor bar and mark dot in front of row i.
+func (m Model) gutter(i int) string {
+	bar, dot := " ", " "
+	if i == m.cursor {
+		bar = m.styles.Cursor.Render("▍")
+	}
+	if m.rowMarked(i) {
+		dot = m.styles.Cursor.Render("•")
+	} else if m.selecting && i == m.anchor {
+		dot = m.styles.ListRowDim.Render("┆")
+	}
+	return bar + dot
+}
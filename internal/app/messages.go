@@ -3,19 +3,102 @@ package app
 import (
 	"time"
 
+	"github.com/AJMerr/gompc/internal/history"
+	"github.com/AJMerr/gompc/internal/lyrics"
 	"github.com/AJMerr/gompc/internal/mpd"
+	"github.com/AJMerr/gompc/internal/stickers"
+	tea "github.com/charmbracelet/bubbletea"
 )
 
-// Connection lifecycle
-type ConnectionMsg struct{ Conn mpd.Conn }
-type ConnectionErrMsg struct{ Err error }
+// Connection lifecycle. Cfg is what was dialled; replies for another
+// server or partition arrive after a switch and are dropped.
+type ConnectionMsg struct {
+	Conn mpd.Conn
+	Cfg  mpd.Config
+}
+type ConnectionErrMsg struct {
+	Err error
+	Cfg mpd.Config
+}
+type IdleConnMsg struct {
+	Conn mpd.Conn
+	Cfg  mpd.Config
+}
+
+// Data. Conn is the connection a reply came from; replies from one that
+// has since been closed (server switch, reconnect) are dropped.
+type LibLoadedMsg struct {
+	Tracks []mpd.Track
+	Conn   mpd.Conn
+}
+type StatusMsg struct {
+	Now  mpd.NowPlaying
+	Conn mpd.Conn
+}
+type DirLoadedMsg struct {
+	Path   string
+	Dirs   []string
+	Tracks []mpd.Track
+	Conn   mpd.Conn
+}
 
-// Data
-type LibLoadedMsg struct{ Tracks []mpd.Track }
-type StatusMsg struct{ Now mpd.NowPlaying }
+type OutputsMsg struct {
+	Outputs []mpd.Output
+	Conn    mpd.Conn
+}
 
-// Server Events
-type IdleEventMsg struct{ Subs []string }
+// Partitions on the server, and the one the main connection moved to
+type PartitionsMsg struct {
+	Names []string
+	Conn  mpd.Conn
+}
+type PartitionMsg struct {
+	Name string
+	Conn mpd.Conn
+}
+
+// Lyrics lookup result for a song (empty Lyrics = none found)
+type LyricsMsg struct {
+	URI    string
+	Lyrics lyrics.Lyrics
+}
+
+// Library stickers; nil DB when the server has no sticker database
+type StickersMsg struct {
+	DB   stickers.DB
+	Conn mpd.Conn
+}
+
+// Stickers we changed, applied locally ahead of the reload
+type StickerChangeMsg struct {
+	Changes []stickers.Change
+	Notice  string
+	Conn    mpd.Conn
+}
+
+// Listening history, newest first
+type HistoryMsg struct{ Entries []history.Entry }
+
+// A play just written to the history
+type HistoryAddMsg struct{ Entry history.Entry }
+
+// Songs the auto-DJ queued
+type AutoDJMsg struct {
+	Added []mpd.Track
+	Conn  mpd.Conn
+}
+
+// Result of a bulk action, shown in the footer
+type NoticeMsg struct{ Text string }
+
+// Server Events, from the idle connection Conn
+type IdleEventMsg struct {
+	Subs []string
+	Conn mpd.Conn
+}
+
+// A partial key sequence timed out, unless keys were pressed after Press
+type KeySeqTimeoutMsg struct{ Press int }
 
 // UI timer tick
 type TickMsg struct{ At time.Time }
@@ -24,3 +107,31 @@ type ErrMsg struct {
 	Op  string
 	Err error
 }
+
+// The connection a reply came from, for the messages that carry one. Idle
+// events come from the idle connection, everything else from the main one.
+func replyConn(msg tea.Msg) (mpd.Conn, bool) {
+	switch msg := msg.(type) {
+	case IdleEventMsg:
+		return msg.Conn, true
+	case LibLoadedMsg:
+		return msg.Conn, true
+	case StatusMsg:
+		return msg.Conn, true
+	case DirLoadedMsg:
+		return msg.Conn, true
+	case OutputsMsg:
+		return msg.Conn, true
+	case PartitionsMsg:
+		return msg.Conn, true
+	case PartitionMsg:
+		return msg.Conn, true
+	case StickersMsg:
+		return msg.Conn, true
+	case StickerChangeMsg:
+		return msg.Conn, true
+	case AutoDJMsg:
+		return msg.Conn, true
+	}
+	return nil, false
+}
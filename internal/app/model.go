@@ -3,8 +3,15 @@ package app
 import (
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/AJMerr/gompc/internal/autodj"
+	"github.com/AJMerr/gompc/internal/history"
+	"github.com/AJMerr/gompc/internal/lyrics"
 	"github.com/AJMerr/gompc/internal/mpd"
+	"github.com/AJMerr/gompc/internal/plays"
+	"github.com/AJMerr/gompc/internal/smart"
+	"github.com/AJMerr/gompc/internal/stickers"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -14,15 +21,42 @@ type Tab int
 const (
 	TabAll Tab = iota
 	TabArtists
+	TabBrowse // a configured Hierarchy
+	TabFolders
+	TabSmart
+	TabHistory
+	TabLyrics
+	TabOutputs
+	TabPartitions
+	TabServers
 )
 
-type Keymap struct {
-	Up, Down     string
-	Tab          string
-	Enter, Space string
-	Next, Prev   string
-	Back         string
-	Quit         string
+type tabSpec struct {
+	kind  Tab
+	label string
+	hier  int // index into Model.hier for TabBrowse
+}
+
+func buildTabs(hier []Hierarchy, servers bool) []tabSpec {
+	tabs := []tabSpec{
+		{kind: TabAll, label: "All"},
+		{kind: TabArtists, label: "Artists"},
+	}
+	for i, h := range hier {
+		tabs = append(tabs, tabSpec{kind: TabBrowse, label: h.Name, hier: i})
+	}
+	tabs = append(tabs,
+		tabSpec{kind: TabFolders, label: "Folders"},
+		tabSpec{kind: TabSmart, label: "Smart"},
+		tabSpec{kind: TabHistory, label: "History"},
+		tabSpec{kind: TabLyrics, label: "Lyrics"},
+		tabSpec{kind: TabOutputs, label: "Outputs"},
+		tabSpec{kind: TabPartitions, label: "Partitions"},
+	)
+	if servers {
+		tabs = append(tabs, tabSpec{kind: TabServers, label: "Servers"})
+	}
+	return tabs
 }
 
 // Heirarchy state for Artists/Albums
@@ -35,10 +69,13 @@ const (
 )
 
 type Model struct {
-	deps Deps
-	conn mpd.Conn
+	deps     Deps
+	conn     mpd.Conn
+	idleConn mpd.Conn // blocked in IdleCmd; never used for commands
 
 	// UI state
+	tabs      []tabSpec
+	tabIdx    int
 	tab       Tab
 	level     Level
 	cursor    int
@@ -46,8 +83,12 @@ type Model struct {
 	lastErr   error
 	connected bool
 
+	updateStart time.Time // when the running database update was first seen
+
 	// Indexes
-	allSongs []mpd.Track
+	index    libIndex
+	libSongs []mpd.Track // library order as returned by MPD
+	allSongs []mpd.Track // All tab, sorted by sortCol
 	artists  []string
 	albums   []string
 	tracks   []mpd.Track
@@ -57,7 +98,66 @@ type Model struct {
 	selectArtist string
 	selectAlbum  string
 
-	keys Keymap
+	// All tab table
+	columns  []Column
+	sortCol  Column // "" = library order
+	sortDesc bool
+
+	// Select mode (see marks.go)
+	selecting bool
+	anchor    int // range start, -1 = unset
+	marks     *markSet
+
+	// Footer prompt and last action notice
+	prompt prompt
+	notice string
+
+	// Browse tabs (see Hierarchy)
+	hier         []Hierarchy
+	browsePath   []string
+	browseItems  []string
+	browseTracks []mpd.Track
+
+	// Folder tab (lsinfo)
+	dir       string
+	dirs      []string
+	dirTracks []mpd.Track
+
+	// Lyrics tab (see lyrics.go)
+	lyrics        lyrics.Lyrics
+	lyricsURI     string // song the lyrics belong to
+	lyricsLoading bool
+	lyricsBack    int // tab to return to when toggling lyrics off
+
+	// Smart tab: each playlist's songs as of the last library/sticker load
+	smart      []*smart.Query
+	smartSongs [][]mpd.Track
+
+	// History tab, newest first
+	history []history.Entry
+
+	// Outputs tab
+	outputs []mpd.Output
+
+	// Partitions tab; the one in use is deps.Cfg.Partition
+	partitions []string
+
+	// Auto-DJ, toggled from the keyboard
+	dj   *autodj.DJ
+	djOn bool
+
+	// Ratings, favourites and play counts; nil without a sticker database
+	stickers stickers.DB
+	plays    *plays.Tracker // counts finished plays
+
+	keys        Keymap
+	pendingKeys []string // partial multi-key sequence
+	keyPresses  int      // counts key presses, to time out pendingKeys
+
+	// Mouse (see mouse.go)
+	lastClickRow int
+	lastClickAt  time.Time
+	seeking      bool // dragging the progress bar
 
 	// Styles
 	width, height int
@@ -65,18 +165,29 @@ type Model struct {
 }
 
 func New(d Deps) Model {
+	cols := d.Columns
+	if len(cols) == 0 {
+		cols = DefaultColumns()
+	}
+	keys := d.Keys
+	if keys.bindings == nil {
+		keys, _ = NewKeymap(nil) // defaults never conflict
+	}
 	return Model{
-		deps:   d,
-		tab:    TabAll,
-		level:  LevelArtist,
-		styles: newStyles(),
-		keys: Keymap{
-			Up: "up/k", Down: "down/j", Tab: "tab",
-			Enter: "enter", Space: "space",
-			Next: "n", Prev: "p", Back: "backspace",
-			Quit: "q",
-		},
+		columns: cols,
+		deps:    d,
+		tabs:    buildTabs(d.Browse, len(d.Profiles) > 0),
+		hier:    d.Browse,
+		smart:   d.Smart,
+		tab:     TabAll,
+		level:   LevelArtist,
+		styles:  newStyles(d.Theme),
+		keys:    keys,
 		loading: true,
+		anchor:  -1,
+		marks:   newMarkSet(),
+		plays:   &plays.Tracker{},
+		dj:      autodj.New(d.AutoDJ),
 	}
 }
 
@@ -144,27 +255,7 @@ func buildIndexes(ts []mpd.Track) libIndex {
 	// Sorts track by track number
 	for k := range tracksByAA {
 		sort.SliceStable(tracksByAA[k], func(i, j int) bool {
-			ti, tj := tracksByAA[k][i], tracksByAA[k][j]
-			// Disc first (0 = unknown -> push to end)
-			di, dj := ti.DiscNo, tj.DiscNo
-			if di != dj {
-				if di == 0 || dj == 0 {
-					return dj == 0 // known discs come before unknown
-				}
-				return di < dj
-			}
-			// Then track number (0 = unknown -> push to end)
-			if ti.TrackNo != tj.TrackNo {
-				if ti.TrackNo == 0 || tj.TrackNo == 0 {
-					return tj.TrackNo == 0 // known tracks before unknown
-				}
-				return ti.TrackNo < tj.TrackNo
-			}
-			// Stable tie-breakers
-			if ti.Title != tj.Title {
-				return ti.Title < tj.Title
-			}
-			return ti.URI < tj.URI
+			return trackLess(tracksByAA[k][i], tracksByAA[k][j])
 		})
 	}
 
@@ -174,3 +265,27 @@ func buildIndexes(ts []mpd.Track) libIndex {
 		TracksByArtistAlbum: tracksByAA,
 	}
 }
+
+// Album order: disc, then track number, then title/URI.
+func trackLess(ti, tj mpd.Track) bool {
+	// Disc first (0 = unknown -> push to end)
+	di, dj := ti.DiscNo, tj.DiscNo
+	if di != dj {
+		if di == 0 || dj == 0 {
+			return dj == 0 // known discs come before unknown
+		}
+		return di < dj
+	}
+	// Then track number (0 = unknown -> push to end)
+	if ti.TrackNo != tj.TrackNo {
+		if ti.TrackNo == 0 || tj.TrackNo == 0 {
+			return tj.TrackNo == 0 // known tracks before unknown
+		}
+		return ti.TrackNo < tj.TrackNo
+	}
+	// Stable tie-breakers
+	if ti.Title != tj.Title {
+		return ti.Title < tj.Title
+	}
+	return ti.URI < tj.URI
+}
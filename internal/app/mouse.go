@@ -0,0 +1,212 @@
+package app
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const doubleClick = 400 * time.Millisecond
+
+// Screen geometry mirrors View: header block, tab row, then the panel with
+// one caption line (breadcrumb or table header) above the list rows.
+
+func (m Model) headerHeight() int {
+	return lipgloss.Height(m.renderHeader())
+}
+
+// Terminal row of the first list row, and the x of the panel's content.
+func (m Model) listOrigin() (x, y int) {
+	p := m.styles.Panel
+	y = m.headerHeight() + 1 + p.GetMarginTop() + p.GetBorderTopSize() + p.GetPaddingTop() + 1
+	x = p.GetMarginLeft() + p.GetBorderLeftSize() + p.GetPaddingLeft()
+	if m.twoColumn() {
+		y-- // the two-column artists view has no breadcrumb line
+	}
+	return x, y
+}
+
+func (m Model) listRows() int {
+	if m.tab == TabAll {
+		return m.maxRowsForList() - 1 // table header
+	}
+	return m.maxRowsForList()
+}
+
+func (m Model) twoColumn() bool {
+	return m.tab == TabArtists && m.width > 90
+}
+
+// Left pane width of the two-column artists view (see artistsViewStyled).
+func (m Model) leftPaneWidth() int {
+	pfw, _ := m.styles.Panel.GetFrameSize()
+	return (max(20, m.width-pfw) * 2) / 5
+}
+
+// Bar geometry inside the header (see headerBlocks).
+func (m Model) progressBar() (x0, y0, w int) {
+	box, _ := m.headerBlocks()
+	o := m.styles.ProgressOuter
+	x0 = lipgloss.Width(box) + 2 + o.GetMarginLeft() + o.GetBorderLeftSize() + o.GetPaddingLeft()
+	y0 = o.GetMarginTop() + o.GetBorderTopSize() + o.GetPaddingTop()
+	return x0, y0, m.barWidth()
+}
+
+// Song position under column x, clamped to the bar.
+func (m Model) progressPos(x int) time.Duration {
+	x0, _, w := m.progressBar()
+	frac := float64(clamp(x-x0, 0, w)) / float64(w)
+	return time.Duration(frac * float64(m.now.Duration))
+}
+
+func (m Model) progressHit(x, y int) bool {
+	if m.now.Duration <= 0 {
+		return false
+	}
+	x0, y0, w := m.progressBar()
+	return y == y0 && x >= x0 && x <= x0+w
+}
+
+func (m Model) tabHit(x int) (int, bool) {
+	pos := 0
+	for i, t := range m.tabs {
+		w := lipgloss.Width(tabLabelStyled(m.styles, i == m.tabIdx, t.label))
+		if x >= pos && x < pos+w {
+			return i, true
+		}
+		pos += w
+	}
+	return 0, false
+}
+
+func (m Model) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if m.prompt.active() {
+		return m, nil
+	}
+
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		return m.handleAction(ActUp)
+	case tea.MouseButtonWheelDown:
+		return m.handleAction(ActDown)
+	}
+
+	// dragging the progress bar: preview locally, seek on release
+	if m.seeking {
+		m.now.Elapsed = m.progressPos(msg.X)
+		if msg.Action == tea.MouseActionRelease {
+			m.seeking = false
+			if m.conn != nil {
+				return m, SeekCmd(m.conn, m.now.Elapsed)
+			}
+		}
+		return m, nil
+	}
+
+	if msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft {
+		return m, nil
+	}
+
+	if m.progressHit(msg.X, msg.Y) {
+		m.seeking = true
+		m.now.Elapsed = m.progressPos(msg.X)
+		return m, nil
+	}
+
+	hh := m.headerHeight()
+	if msg.Y == hh {
+		if i, ok := m.tabHit(msg.X); ok && i != m.tabIdx {
+			return m.switchTab(i)
+		}
+		return m, nil
+	}
+
+	x0, y0 := m.listOrigin()
+	k := msg.Y - y0
+	if k < 0 || k >= m.listRows() {
+		return m, nil
+	}
+
+	if m.twoColumn() {
+		right := msg.X >= x0+m.leftPaneWidth()+2
+		if next, cmd, ok := m.clickArtistsPane(right, k); ok {
+			return next, cmd
+		}
+	}
+
+	// clicking a synced lyric line seeks to it
+	if m.tab == TabLyrics && m.lyrics.Synced {
+		start, _ := windowAroundCursor(m.lyricsFocus(), m.listRows(), len(m.lyrics.Lines))
+		if i := start + k; i < len(m.lyrics.Lines) && m.conn != nil {
+			m.now.Elapsed = m.lyrics.Lines[i].At
+			return m, SeekCmd(m.conn, m.now.Elapsed)
+		}
+		return m, nil
+	}
+
+	start, _ := windowAroundCursor(m.cursor, m.listRows(), m.listLen())
+	i := start + k
+	if i >= m.listLen() {
+		return m, nil
+	}
+	return m.clickRow(i)
+}
+
+// Moves the cursor to row i; a second click on the same row activates it.
+func (m Model) clickRow(i int) (tea.Model, tea.Cmd) {
+	now := time.Now()
+	double := i == m.lastClickRow && now.Sub(m.lastClickAt) < doubleClick
+	m.cursor = i
+	m.lastClickRow, m.lastClickAt = i, now
+	if double {
+		m.lastClickAt = time.Time{}
+		return m.handleAction(ActEnter)
+	}
+	return m, nil
+}
+
+// Two-column artists view: the pane that isn't the cursor list previews the
+// next (or, on tracks, the previous) level; clicking it moves there.
+func (m Model) clickArtistsPane(right bool, k int) (tea.Model, tea.Cmd, bool) {
+	switch {
+	case m.level == LevelArtist && right:
+		if len(m.artists) == 0 {
+			return m, nil, true
+		}
+		artist := m.artists[m.cursor]
+		if k >= len(m.index.AlbumsByArtist[artist]) {
+			return m, nil, true
+		}
+		m.selectArtist = artist
+		m.albums = m.index.AlbumsByArtist[artist]
+		m.level = LevelAlbum
+		m.cursor = k
+		return m, nil, true
+
+	case m.level == LevelAlbum && right:
+		if len(m.albums) == 0 {
+			return m, nil, true
+		}
+		album := m.albums[m.cursor]
+		trs := m.index.TracksByArtistAlbum[keyAA(m.selectArtist, album)]
+		if k >= len(trs) {
+			return m, nil, true
+		}
+		m.selectAlbum = album
+		m.tracks = trs
+		m.level = LevelTrack
+		m.cursor = k
+		return m, nil, true
+
+	case m.level == LevelTrack && !right:
+		if k >= len(m.albums) {
+			return m, nil, true
+		}
+		m.selectAlbum = m.albums[k]
+		m.tracks = m.index.TracksByArtistAlbum[keyAA(m.selectArtist, m.selectAlbum)]
+		m.cursor = 0
+		return m, nil, true
+	}
+	return m, nil, false
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"github.com/AJMerr/gompc/internal/plays"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The partition the connections work on.
+func (m Model) partition() string {
+	return nz(m.deps.Cfg.Partition, "default")
+}
+
+func partitionsViewStyled(m Model) string {
+	s := m.styles
+	crumb := s.Breadcrumb.Render("Partitions › Enter switches • gompc partitions to add or move outputs") + "\n"
+	cur := m.partition()
+	labels := make([]string, len(m.partitions))
+	for i, name := range m.partitions {
+		mark := "  "
+		if name == cur {
+			mark = "● "
+		}
+		labels[i] = mark + name
+	}
+	return crumb + plainListStyled(m, labels, "(no partitions; MPD 0.22+ has them)")
+}
+
+// The main connection moved to name: redial the idle one there too and
+// reload what differs per partition. The library is shared. Events still
+// queued from the old idle connection no longer match m.idleConn and are
+// dropped in Update, so only the redialled one resubscribes.
+func (m Model) switchedPartition(name string) (Model, tea.Cmd) {
+	if name == "default" {
+		name = ""
+	}
+	m.deps.Cfg.Partition = name
+	if m.idleConn != nil {
+		_ = m.idleConn.Close()
+		m.idleConn = nil
+	}
+	m.plays = &plays.Tracker{} // another player, not a skip
+	m.outputs = nil
+	m.notice = "switched to partition " + m.partition()
+	return m, tea.Batch(StatusCmd(m.conn), IdleConnectCmd(m.deps))
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// One-line text input shown in the footer (search, playlist name, ...)
+type promptKind int
+
+const (
+	promptNone promptKind = iota
+	promptSearch
+	promptPlaylist
+	promptSmartSave
+)
+
+type prompt struct {
+	kind  promptKind
+	label string
+	value string
+}
+
+func (p prompt) active() bool { return p.kind != promptNone }
+
+// Handles a key while the prompt is open. done reports enter (submit) or
+// esc (cancel); the prompt is reset in both cases.
+func (p prompt) update(msg tea.KeyMsg) (next prompt, submitted, done bool) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		return prompt{}, true, true
+	case tea.KeyEsc, tea.KeyCtrlC:
+		return prompt{}, false, true
+	case tea.KeyBackspace:
+		if r := []rune(p.value); len(r) > 0 {
+			p.value = string(r[:len(r)-1])
+		}
+	case tea.KeySpace:
+		p.value += " "
+	case tea.KeyRunes:
+		p.value += string(msg.Runes)
+	}
+	return p, false, false
+}
+
+func (p prompt) view(s Styles) string {
+	return s.HeaderBadge.Render(p.label+": ") + s.ListRow.Render(p.value) + s.Cursor.Render("▏")
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+	"github.com/AJMerr/gompc/internal/plays"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// A named server from the config, listed on the Servers tab.
+type Profile struct {
+	Name     string
+	Cfg      mpd.Config
+	MusicDir string // for sidecar lyrics; empty for a remote server
+}
+
+func serversViewStyled(m Model) string {
+	s := m.styles
+	crumb := s.Breadcrumb.Render("Servers › Enter connects") + "\n"
+	labels := make([]string, len(m.deps.Profiles))
+	for i, p := range m.deps.Profiles {
+		mark := "  "
+		if p.Name == m.deps.Profile {
+			mark = "● "
+		}
+		labels[i] = mark + p.Name + s.ListRowDim.Render(" "+net.JoinHostPort(p.Cfg.Host, strconv.Itoa(p.Cfg.Port)))
+	}
+	return crumb + plainListStyled(m, labels, "(no [profiles] in the config)")
+}
+
+// Drops both connections and dials p. Replies and idle events for the old
+// server that are still in flight are ignored (see ConnectionMsg, replyConn).
+func (m Model) switchServer(p Profile) (Model, tea.Cmd) {
+	if m.conn != nil {
+		_ = m.conn.Close()
+	}
+	if m.idleConn != nil {
+		_ = m.idleConn.Close()
+	}
+	m.conn, m.idleConn = nil, nil
+	m.connected = false
+	m.loading = true
+	m.lastErr = nil
+	m.deps.Cfg = p.Cfg
+	m.deps.Lyrics.MusicDir = p.MusicDir
+	m.deps.Profile = p.Name
+	m.now = mpd.NowPlaying{}
+	m.plays = &plays.Tracker{} // a different server isn't a skip
+	m.outputs = nil
+	m.partitions = nil
+	m.stickers = nil
+	m.notice = fmt.Sprintf("connecting to %s…", p.Name)
+	return m, ConnectCmd(m.deps)
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+)
+
+// Re-evaluates the smart playlists against the loaded library and stickers.
+func (m Model) refreshSmart() Model {
+	m.smartSongs = make([][]mpd.Track, len(m.smart))
+	for i, q := range m.smart {
+		m.smartSongs[i] = q.Select(m.libSongs, m.stickers, time.Now())
+	}
+	return m
+}
+
+// Songs of smart playlist i, picked again so random ones reshuffle.
+func (m Model) smartPick(i int) []mpd.Track {
+	if i < 0 || i >= len(m.smart) {
+		return nil
+	}
+	return m.smart[i].Select(m.libSongs, m.stickers, time.Now())
+}
+
+func smartViewStyled(m Model) string {
+	s := m.styles
+	crumb := s.Breadcrumb.Render("Smart › Enter plays • "+m.keys.label(ActSaveSmart)+" saves as playlist") + "\n"
+	labels := make([]string, len(m.smart))
+	for i, q := range m.smart {
+		var total time.Duration
+		for _, t := range m.smartSongs[i] {
+			total += t.Duration
+		}
+		info := fmt.Sprintf("  %d songs, %s · %s", len(m.smartSongs[i]), clockDur(total), q.Source.Describe())
+		labels[i] = q.Source.Name + s.ListRowDim.Render(info)
+	}
+	return crumb + plainListStyled(m, labels, "(no smart playlists; see gompc smart new)")
+}
@@ -0,0 +1,61 @@
+package app
+
+import (
+	"slices"
+	"time"
+
+	"github.com/AJMerr/gompc/internal/history"
+	"github.com/AJMerr/gompc/internal/mpd"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Columns filled from m.stickers
+var stickerCols = []Column{ColRating, ColFavourite, ColPlays, ColLastPlayed}
+
+func (m Model) sortsBy(cols ...Column) bool {
+	return m.sortCol != "" && slices.Contains(cols, m.sortCol)
+}
+
+// The track under the cursor; false on artist, album and folder rows.
+func (m Model) cursorTrack() (mpd.Track, bool) {
+	ts := m.rowTracks(m.cursor)
+	if len(ts) != 1 {
+		return mpd.Track{}, false
+	}
+	switch m.tab {
+	case TabBrowse:
+		if m.cursor < len(m.browseItems) {
+			return mpd.Track{}, false
+		}
+	case TabFolders:
+		if m.cursor < len(m.dirs) {
+			return mpd.Track{}, false
+		}
+	case TabArtists:
+		if m.level != LevelTrack {
+			return mpd.Track{}, false
+		}
+	}
+	return ts[0], true
+}
+
+// Feeds the player state to the play tracker. A finished play bumps the
+// play count when there's a sticker database; finished and skipped plays
+// both go to the history.
+func (m Model) observePlay() tea.Cmd {
+	if m.seeking || m.conn == nil {
+		return nil
+	}
+	p, ok := m.plays.Observe(m.now, time.Now())
+	if !ok {
+		return nil
+	}
+	var cmds []tea.Cmd
+	if p.Finished && m.stickers != nil {
+		cmds = append(cmds, RecordPlayCmd(m.conn, p.Song.URI, p.Ended))
+	}
+	if e, ok := history.FromPlay(p); ok && m.deps.History != "" {
+		cmds = append(cmds, AppendHistoryCmd(m.deps.History, e))
+	}
+	return tea.Batch(cmds...)
+}
@@ -2,20 +2,12 @@ package app
 
 import "github.com/charmbracelet/lipgloss"
 
-// Lucy palette
-var (
-	colBg     = lipgloss.Color("#180f6e")
-	colFg     = lipgloss.Color("#dfc0e9")
-	colPurple = lipgloss.Color("#7a258d")
-	colMuted  = lipgloss.Color("#8771a6")
-	colAccent = lipgloss.Color("#fada16")
-)
-
 type Styles struct {
 	AppTitle      lipgloss.Style
 	Header        lipgloss.Style
 	HeaderNow     lipgloss.Style
 	HeaderBadge   lipgloss.Style
+	HeaderSep     lipgloss.Style
 	TabActive     lipgloss.Style
 	TabInactive   lipgloss.Style
 	Body          lipgloss.Style
@@ -30,41 +22,54 @@ type Styles struct {
 	ProgressFill  lipgloss.Style
 }
 
-func newStyles() Styles {
-	base := lipgloss.NewStyle().Foreground(colFg)
+func newStyles(t Theme) Styles {
+	if t.Name == "" {
+		t = builtinThemes["lucy"]
+	}
+	base := lipgloss.NewStyle().Foreground(t.Fg)
 
-	return Styles{
+	s := Styles{
 		AppTitle:    base.Bold(true),
-		Header:      base.Background(colBg).Padding(0, 1),
+		Header:      base.Background(t.Bg).Padding(0, 1),
 		HeaderNow:   base.Faint(true),
-		HeaderBadge: base.Foreground(colAccent).Bold(true),
+		HeaderBadge: base.Foreground(t.Accent).Bold(true),
+		HeaderSep:   lipgloss.NewStyle().Foreground(t.Muted),
 
-		TabActive:   base.Bold(true).Background(colPurple).Padding(0, 1).MarginRight(1),
-		TabInactive: base.Foreground(colMuted).Padding(0, 1).MarginRight(1),
+		TabActive:   base.Bold(true).Background(t.Highlight).Padding(0, 1).MarginRight(1),
+		TabInactive: base.Foreground(t.Muted).Padding(0, 1).MarginRight(1),
 
 		Body:       base.Padding(1, 2),
 		ListRow:    base,
-		ListRowDim: base.Foreground(colMuted),
-		Cursor:     base.Foreground(colAccent),
+		ListRowDim: base.Foreground(t.Muted),
+		Cursor:     base.Foreground(t.Accent),
 
-		Breadcrumb: base.Foreground(colMuted),
-		Footer:     base.Foreground(colMuted).Padding(0, 1),
-		Error:      base.Foreground(lipgloss.Color("#ff6b6b")),
+		Breadcrumb: base.Foreground(t.Muted),
+		Footer:     base.Foreground(t.Muted).Padding(0, 1),
+		Error:      base.Foreground(t.Error),
 
 		Panel: base.
 			BorderStyle(lipgloss.RoundedBorder()).
-			BorderForeground(colPurple).
+			BorderForeground(t.Highlight).
 			Padding(0, 1).
 			Margin(0, 1),
 
 		ProgressOuter: lipgloss.NewStyle().
 			BorderStyle(lipgloss.NormalBorder()).
-			BorderForeground(colMuted).
+			BorderForeground(t.Muted).
 			Padding(0, 1),
 
 		ProgressFill: lipgloss.NewStyle().
-			Foreground(colBg).
-			Background(colAccent),
+			Foreground(t.Bg).
+			Background(t.Accent),
 	}
-}
 
+	if t.Mono {
+		s.TabActive = s.TabActive.Reverse(true)
+		s.HeaderBadge = s.HeaderBadge.Underline(true)
+		s.ListRowDim = s.ListRowDim.Faint(true)
+		s.Breadcrumb = s.Breadcrumb.Faint(true)
+		s.Cursor = s.Cursor.Bold(true)
+		s.ProgressFill = s.ProgressFill.Reverse(true)
+	}
+	return applyOverrides(s, t.Overrides)
+}
@@ -0,0 +1,295 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+	"github.com/AJMerr/gompc/internal/stickers"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Columns of the All tab track table
+type Column string
+
+const (
+	ColTrack    Column = "track"
+	ColTitle    Column = "title"
+	ColArtist   Column = "artist"
+	ColAlbum    Column = "album"
+	ColYear     Column = "year"
+	ColGenre    Column = "genre"
+	ColDuration Column = "duration"
+
+	// From stickers (see internal/stickers)
+	ColRating     Column = "rating"
+	ColFavourite  Column = "favourite"
+	ColPlays      Column = "plays"
+	ColLastPlayed Column = "last_played"
+)
+
+type colSpec struct {
+	header string
+	width  int // fixed width; 0 = flexible
+	weight int // share of the leftover width for flexible columns
+	drop   int // narrow terminals drop higher values first
+}
+
+var colSpecs = map[Column]colSpec{
+	ColTrack:    {header: "#", width: 4, drop: 3},
+	ColTitle:    {header: "Title", weight: 3},
+	ColArtist:   {header: "Artist", weight: 2, drop: 1},
+	ColAlbum:    {header: "Album", weight: 2, drop: 4},
+	ColYear:     {header: "Year", width: 4, drop: 5},
+	ColGenre:    {header: "Genre", weight: 1, drop: 6},
+	ColDuration: {header: "Time", width: 6, drop: 2},
+
+	ColRating:     {header: "Rating", width: 6, drop: 7},
+	ColFavourite:  {header: "♥", width: 1, drop: 8},
+	ColPlays:      {header: "Plays", width: 5, drop: 9},
+	ColLastPlayed: {header: "Last played", width: 11, drop: 10},
+}
+
+func DefaultColumns() []Column {
+	return []Column{ColTrack, ColTitle, ColArtist, ColAlbum, ColYear, ColGenre, ColDuration}
+}
+
+func ParseColumns(names []string) ([]Column, error) {
+	out := make([]Column, 0, len(names))
+	for _, n := range names {
+		c := Column(strings.ToLower(strings.TrimSpace(n)))
+		if _, ok := colSpecs[c]; !ok {
+			return nil, fmt.Errorf("unknown column %q", n)
+		}
+		out = append(out, c)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no columns configured")
+	}
+	return out, nil
+}
+
+func cellValue(t mpd.Track, c Column) string {
+	switch c {
+	case ColTrack:
+		if t.TrackNo == 0 {
+			return ""
+		}
+		if t.DiscNo > 0 {
+			return fmt.Sprintf("%d.%02d", t.DiscNo, t.TrackNo)
+		}
+		return strconv.Itoa(t.TrackNo)
+	case ColTitle:
+		if t.Title == "" {
+			return baseNameFromURI(t.URI)
+		}
+		return t.Title
+	case ColArtist:
+		return nz(t.Artist, "<unknown>")
+	case ColAlbum:
+		return t.Album
+	case ColYear:
+		if t.Year == 0 {
+			return ""
+		}
+		return strconv.Itoa(t.Year)
+	case ColGenre:
+		return t.Genre
+	case ColDuration:
+		if t.Duration <= 0 {
+			return ""
+		}
+		return clockDur(t.Duration)
+	}
+	return ""
+}
+
+// Sticker columns; ok=false for tag columns.
+func stickerCell(s stickers.Song, c Column) (string, bool) {
+	switch c {
+	case ColRating:
+		return strings.Repeat("★", s.Rating), true
+	case ColFavourite:
+		if s.Favourite {
+			return "♥", true
+		}
+		return "", true
+	case ColPlays:
+		if s.PlayCount == 0 {
+			return "", true
+		}
+		return strconv.Itoa(s.PlayCount), true
+	case ColLastPlayed:
+		if s.LastPlayed.IsZero() {
+			return "", true
+		}
+		return s.LastPlayed.Format("2006-01-02"), true
+	}
+	return "", false
+}
+
+func colLess(a, b mpd.Track, c Column, db stickers.DB) bool {
+	sa, sb := db[a.URI], db[b.URI]
+	switch c {
+	case ColRating:
+		return sa.Rating < sb.Rating
+	case ColFavourite:
+		return !sa.Favourite && sb.Favourite
+	case ColPlays:
+		return sa.PlayCount < sb.PlayCount
+	case ColLastPlayed:
+		return sa.LastPlayed.Before(sb.LastPlayed)
+	case ColTrack:
+		return trackLess(a, b)
+	case ColYear:
+		return a.Year < b.Year
+	case ColDuration:
+		return a.Duration < b.Duration
+	}
+	return strings.ToLower(cellValue(a, c)) < strings.ToLower(cellValue(b, c))
+}
+
+// Sorts a copy of the library; ties keep library order so the result is
+// the same on every refresh.
+func sortTracks(ts []mpd.Track, c Column, desc bool, db stickers.DB) []mpd.Track {
+	out := append([]mpd.Track(nil), ts...)
+	if c == "" {
+		return out
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if desc {
+			return colLess(out[j], out[i], c, db)
+		}
+		return colLess(out[i], out[j], c, db)
+	})
+	return out
+}
+
+// Re-sorts the All tab, keeping the cursor on the same track.
+func (m Model) resortAll() Model {
+	uri := ""
+	if m.cursor < len(m.allSongs) {
+		uri = m.allSongs[m.cursor].URI
+	}
+	m.allSongs = sortTracks(m.libSongs, m.sortCol, m.sortDesc, m.stickers)
+	if m.tab == TabAll {
+		m.cursor = 0
+		for i, t := range m.allSongs {
+			if t.URI == uri {
+				m.cursor = i
+				break
+			}
+		}
+	}
+	return m
+}
+
+// Cycles the sort through the visible columns, then back to library order.
+func (m Model) nextSortCol() Model {
+	i := -1
+	for k, c := range m.columns {
+		if c == m.sortCol {
+			i = k
+		}
+	}
+	if i+1 < len(m.columns) {
+		m.sortCol = m.columns[i+1]
+	} else {
+		m.sortCol = ""
+	}
+	m.sortDesc = false
+	return m.resortAll()
+}
+
+// Fits the visible columns into width, dropping low-priority ones when it
+// gets too tight.
+func layoutColumns(cols []Column, width int) ([]Column, []int) {
+	const gap, minFlex = 1, 6
+	cols = append([]Column(nil), cols...)
+	for {
+		fixed, weights := 0, 0
+		for _, c := range cols {
+			sp := colSpecs[c]
+			fixed += sp.width + gap
+			weights += sp.weight
+		}
+		flex := width - fixed
+		if len(cols) == 1 || weights == 0 || flex >= weights*minFlex {
+			widths := make([]int, len(cols))
+			used := 0
+			last := -1
+			for i, c := range cols {
+				sp := colSpecs[c]
+				if sp.width > 0 {
+					widths[i] = sp.width
+					continue
+				}
+				widths[i] = max(1, flex*sp.weight/max(1, weights))
+				used += widths[i]
+				last = i
+			}
+			if last >= 0 {
+				widths[last] += max(0, flex-used)
+			}
+			return cols, widths
+		}
+		// drop the most expendable column
+		worst := 0
+		for i, c := range cols {
+			if colSpecs[c].drop > colSpecs[cols[worst]].drop {
+				worst = i
+			}
+		}
+		cols = append(cols[:worst], cols[worst+1:]...)
+	}
+}
+
+func padTo(width int, s string) string {
+	if lipgloss.Width(s) > width {
+		s = fitTo(width, s)
+	}
+	if w := lipgloss.Width(s); w < width {
+		s += strings.Repeat(" ", width-w)
+	}
+	return s
+}
+
+func (m Model) tableHeader(cols []Column, widths []int) string {
+	cells := make([]string, len(cols))
+	for i, c := range cols {
+		h := colSpecs[c].header
+		if c == m.sortCol {
+			if m.sortDesc {
+				h += " ▼"
+			} else {
+				h += " ▲"
+			}
+		}
+		cells[i] = padTo(widths[i], h)
+	}
+	return "  " + strings.Join(cells, " ")
+}
+
+func tableRow(t mpd.Track, st stickers.Song, cols []Column, widths []int) string {
+	cells := make([]string, len(cols))
+	for i, c := range cols {
+		v, ok := stickerCell(st, c)
+		if !ok {
+			v = cellValue(t, c)
+		}
+		cells[i] = padTo(widths[i], v)
+	}
+	return strings.Join(cells, " ")
+}
+
+// m:ss, or h:mm:ss for long tracks
+func clockDur(d time.Duration) string {
+	s := int(d.Round(time.Second) / time.Second)
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
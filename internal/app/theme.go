@@ -0,0 +1,279 @@
+package app
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// A Theme is a palette plus optional per-style overrides.
+type Theme struct {
+	Name string
+
+	Bg, Fg    lipgloss.Color
+	Highlight lipgloss.Color // active tab, panel border
+	Muted     lipgloss.Color
+	Accent    lipgloss.Color // cursor, badges, progress
+	Error     lipgloss.Color
+
+	// Monochrome terminals: lean on reverse/bold instead of colour
+	Mono bool
+
+	Overrides map[string]StyleSpec // Styles field name -> override
+}
+
+// Theme as written in config.toml:
+//
+//	[themes.mine]
+//	base = "lucy"
+//	accent = "#ff8800"
+//	[themes.mine.styles.cursor]
+//	fg = "#00ff00"
+//	bold = true
+type ThemeSpec struct {
+	Base      string
+	Bg, Fg    string
+	Highlight string
+	Muted     string
+	Accent    string
+	Error     string
+	Styles    map[string]StyleSpec
+}
+
+type StyleSpec struct {
+	Fg, Bg, Border string
+	Bold           *bool
+	Italic         *bool
+	Underline      *bool
+	Faint          *bool
+	Reverse        *bool
+}
+
+const DefaultThemeName = "auto"
+
+var builtinThemes = map[string]Theme{
+	// Lucy palette
+	"lucy": {
+		Name:      "lucy",
+		Bg:        "#180f6e",
+		Fg:        "#dfc0e9",
+		Highlight: "#7a258d",
+		Muted:     "#8771a6",
+		Accent:    "#fada16",
+		Error:     "#ff6b6b",
+	},
+	"light": {
+		Name:      "light",
+		Bg:        "#e8e4f0",
+		Fg:        "#2b2340",
+		Highlight: "#c9b8e8",
+		Muted:     "#7d7490",
+		Accent:    "#b3261e",
+		Error:     "#c00000",
+	},
+	"high-contrast": {
+		Name:      "high-contrast",
+		Bg:        "#000000",
+		Fg:        "#ffffff",
+		Highlight: "#0000ff",
+		Muted:     "#c0c0c0",
+		Accent:    "#ffff00",
+		Error:     "#ff0000",
+	},
+	// Plain 16-colour ANSI; follows the terminal's own palette
+	"ansi": {
+		Name:      "ansi",
+		Bg:        "4",
+		Fg:        "7",
+		Highlight: "5",
+		Muted:     "8",
+		Accent:    "11",
+		Error:     "9",
+	},
+	"mono": {Name: "mono", Mono: true},
+}
+
+func BuiltinThemeNames() []string {
+	names := make([]string, 0, len(builtinThemes))
+	for n := range builtinThemes {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Picks a theme by name from user specs and built-ins. "auto" chooses
+// from the terminal colour profile (NO_COLOR yields Ascii).
+func ResolveTheme(name string, user map[string]ThemeSpec, profile termenv.Profile) (Theme, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || name == DefaultThemeName {
+		switch profile {
+		case termenv.Ascii:
+			name = "mono"
+		case termenv.ANSI:
+			name = "ansi"
+		default:
+			name = "lucy"
+		}
+	}
+	t, err := lookupTheme(name, user, 0)
+	if err != nil {
+		return Theme{}, err
+	}
+	if profile == termenv.Ascii {
+		t.Mono = true
+	}
+	return t, nil
+}
+
+func lookupTheme(name string, user map[string]ThemeSpec, depth int) (Theme, error) {
+	if depth > 8 {
+		return Theme{}, fmt.Errorf("theme %q: base chain too deep", name)
+	}
+	spec, ok := user[name]
+	if !ok {
+		if t, ok := builtinThemes[name]; ok {
+			return t, nil
+		}
+		return Theme{}, fmt.Errorf("unknown theme %q", name)
+	}
+	base := strings.ToLower(spec.Base)
+	if base == "" || base == name {
+		base = "lucy"
+	}
+	t, err := lookupTheme(base, user, depth+1)
+	if err != nil {
+		return Theme{}, fmt.Errorf("theme %q: %w", name, err)
+	}
+	t.Name = name
+	for _, c := range []struct {
+		dst *lipgloss.Color
+		val string
+	}{
+		{&t.Bg, spec.Bg}, {&t.Fg, spec.Fg}, {&t.Highlight, spec.Highlight},
+		{&t.Muted, spec.Muted}, {&t.Accent, spec.Accent}, {&t.Error, spec.Error},
+	} {
+		if c.val == "" {
+			continue
+		}
+		if !validColor(c.val) {
+			return Theme{}, fmt.Errorf("theme %q: bad colour %q", name, c.val)
+		}
+		*c.dst = lipgloss.Color(c.val)
+	}
+	over := map[string]StyleSpec{}
+	for k, v := range t.Overrides {
+		over[k] = v
+	}
+	for field, st := range spec.Styles {
+		f, ok := styleField(field)
+		if !ok {
+			return Theme{}, fmt.Errorf("theme %q: unknown style %q", name, field)
+		}
+		for _, c := range []string{st.Fg, st.Bg, st.Border} {
+			if c != "" && !validColor(c) {
+				return Theme{}, fmt.Errorf("theme %q: bad colour %q in %s", name, c, field)
+			}
+		}
+		over[f] = st
+	}
+	t.Overrides = over
+	return t, nil
+}
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// "#rgb", "#rrggbb" or an ANSI index 0-255
+func validColor(s string) bool {
+	if hexColor.MatchString(s) {
+		return true
+	}
+	n, err := strconv.Atoi(s)
+	return err == nil && n >= 0 && n <= 255
+}
+
+// Case-insensitive Styles field lookup (viper lowercases TOML keys).
+func styleField(name string) (string, bool) {
+	rt := reflect.TypeOf(Styles{})
+	for i := range rt.NumField() {
+		if strings.EqualFold(rt.Field(i).Name, name) {
+			return rt.Field(i).Name, true
+		}
+	}
+	return "", false
+}
+
+func (sp StyleSpec) apply(st lipgloss.Style) lipgloss.Style {
+	if sp.Fg != "" {
+		st = st.Foreground(lipgloss.Color(sp.Fg))
+	}
+	if sp.Bg != "" {
+		st = st.Background(lipgloss.Color(sp.Bg))
+	}
+	if sp.Border != "" {
+		st = st.BorderForeground(lipgloss.Color(sp.Border))
+	}
+	if sp.Bold != nil {
+		st = st.Bold(*sp.Bold)
+	}
+	if sp.Italic != nil {
+		st = st.Italic(*sp.Italic)
+	}
+	if sp.Underline != nil {
+		st = st.Underline(*sp.Underline)
+	}
+	if sp.Faint != nil {
+		st = st.Faint(*sp.Faint)
+	}
+	if sp.Reverse != nil {
+		st = st.Reverse(*sp.Reverse)
+	}
+	return st
+}
+
+func applyOverrides(s Styles, over map[string]StyleSpec) Styles {
+	v := reflect.ValueOf(&s).Elem()
+	for name, sp := range over {
+		f := v.FieldByName(name)
+		if !f.IsValid() {
+			continue
+		}
+		f.Set(reflect.ValueOf(sp.apply(f.Interface().(lipgloss.Style))))
+	}
+	return s
+}
+
+// Sample screen for `gompc themes`.
+func PreviewTheme(t Theme, width int) string {
+	s := newStyles(t)
+	m := Model{
+		styles:    s,
+		width:     width,
+		connected: true,
+		tabs:      buildTabs(DefaultHierarchies(), false),
+		columns:   DefaultColumns(),
+	}
+	m.now.Artist, m.now.Title, m.now.Album = "Lucy", "Sky", "Diamonds"
+	m.now.Duration, m.now.Elapsed, m.now.Playing = 200e9, 80e9, true
+
+	labels := make([]string, 0, len(m.tabs))
+	for i, tb := range m.tabs {
+		labels = append(labels, tabLabelStyled(s, i == 0, tb.label))
+	}
+	rows := s.ListRow.Bold(true).Render(s.Cursor.Render("▍")+s.Cursor.Render("•")+" Current row") + "\n" +
+		s.ListRow.Render("   Normal row") + "\n" +
+		s.ListRowDim.Render("   Dim row") + "\n" +
+		s.Breadcrumb.Render("Artists › Breadcrumb") + "\n" +
+		s.Error.Render("ERR: error text")
+	pfw, _ := s.Panel.GetFrameSize()
+	return m.renderHeader() + "\n" +
+		lipgloss.JoinHorizontal(lipgloss.Top, labels...) + "\n" +
+		s.Panel.Width(max(20, width-pfw)).Render(rows) + "\n" +
+		s.Footer.Render("footer help")
+}
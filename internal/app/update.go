@@ -1,33 +1,68 @@
 package app
 
 import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if c, ok := replyConn(msg); ok {
+		want := m.conn
+		if _, idle := msg.(IdleEventMsg); idle {
+			want = m.idleConn
+		}
+		if c != want {
+			return m, nil // from a connection closed since; want may be nil
+		}
+	}
 	switch msg := msg.(type) {
 
 	case ConnectionMsg:
+		if msg.Cfg != m.deps.Cfg {
+			_ = msg.Conn.Close()
+			return m, nil
+		}
 		m.conn = msg.Conn
 		m.connected = true
+		m.notice = "" // "connecting to …"
 		m.loading = true
 		return m, tea.Batch(
 			FetchLibraryCmd(m.conn),
 			StatusCmd(m.conn),
-			IdleCmd(m.conn, []string{"player", "database"}),
+			IdleConnectCmd(m.deps),
 		)
 
+	case IdleConnMsg:
+		if msg.Cfg != m.deps.Cfg {
+			_ = msg.Conn.Close()
+			return m, nil
+		}
+		m.idleConn = msg.Conn
+		return m, IdleCmd(m.idleConn, idleSubs)
+
 	case ConnectionErrMsg:
+		if msg.Cfg != m.deps.Cfg {
+			return m, nil
+		}
 		m.lastErr = msg.Err
 		m.connected = false
 		m.loading = false
+		m.notice = ""
 		return m, nil
 
 	case LibLoadedMsg:
 		m.loading = false
-		m.allSongs = msg.Tracks
+		m.libSongs = msg.Tracks
+		m = m.resortAll().refreshSmart()
 
 		idx := buildIndexes(m.allSongs)
+		m.index = idx
 		m.artists = idx.Artists
 		m.albums = nil
 		m.tracks = nil
@@ -36,144 +71,633 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.tab == TabArtists {
 			m.cursor = 0
 		}
+		if m.tab == TabBrowse {
+			m.browsePath = nil
+			m = m.refreshBrowse()
+			m.cursor = 0
+		}
+		return m, LoadStickersCmd(m.conn)
+
+	case StickersMsg:
+		m.stickers = msg.DB
+		if m.sortsBy(stickerCols...) {
+			m = m.resortAll()
+		}
+		return m.refreshSmart(), nil
+
+	case StickerChangeMsg:
+		if m.stickers != nil {
+			for _, c := range msg.Changes {
+				m.stickers.Apply(c)
+			}
+			if m.sortsBy(stickerCols...) {
+				m = m.resortAll()
+			}
+			m = m.refreshSmart()
+		}
+		if msg.Notice != "" {
+			m.notice = msg.Notice
+			m.lastErr = nil
+		}
+		return m, nil
+
+	case DirLoadedMsg:
+		m.dir = msg.Path
+		m.dirs = msg.Dirs
+		m.dirTracks = msg.Tracks
+		m.cursor = 0
 		return m, nil
 
 	case StatusMsg:
+		if m.seeking {
+			msg.Now.Elapsed = m.now.Elapsed // mid-drag; keep the preview
+		}
+		switch {
+		case msg.Now.UpdatingDB == 0:
+			m.updateStart = time.Time{}
+		case m.updateStart.IsZero():
+			m.updateStart = time.Now()
+		}
 		m.now = msg.Now
+		played := m.observePlay()
+		m, cmd := m.syncLyrics()
+		var notified tea.Cmd
+		if m.deps.Notify != nil {
+			notified = NotifyCmd(m.deps.Notify, m.conn, m.now)
+		}
+		return m, tea.Batch(cmd, played, notified)
+
+	case HistoryMsg:
+		m.history = msg.Entries
+		return m, nil
+
+	case HistoryAddMsg:
+		m.history = slices.Insert(m.history, 0, msg.Entry)
+		if m.tab == TabHistory && m.cursor > 0 {
+			m.cursor++ // stay on the same row
+		}
+		return m, nil
+
+	case AutoDJMsg:
+		if n := len(msg.Added); n > 0 {
+			t := msg.Added[n-1]
+			m.notice = "auto-DJ queued " + nz(t.Artist, "<unknown>") + " — " + cellValue(t, ColTitle)
+			if n > 1 {
+				m.notice += fmt.Sprintf(" and %d more", n-1)
+			}
+		}
+		return m, nil
+
+	case PartitionsMsg:
+		m.partitions = msg.Names
+		if m.tab == TabPartitions {
+			m.cursor = clamp(m.cursor, 0, max(0, len(m.partitions)-1))
+		}
+		return m, nil
+
+	case PartitionMsg:
+		return m.switchedPartition(msg.Name)
+
+	case OutputsMsg:
+		m.outputs = msg.Outputs
+		if m.tab == TabOutputs {
+			m.cursor = clamp(m.cursor, 0, max(0, len(m.outputs)-1))
+		}
+		return m, nil
+
+	case LyricsMsg:
+		if msg.URI == m.lyricsURI {
+			m.lyrics = msg.Lyrics
+			m.lyricsLoading = false
+		}
 		return m, nil
 
 	case IdleEventMsg:
+		if m.conn == nil || m.idleConn == nil {
+			return m, nil // mid switch; the new idle loop starts on IdleConnMsg
+		}
 		// React to server events; always resubscribe
-		// - if "player" in Subs: refresh status
-		// - if "database" in Subs: refetch library
-		return m, tea.Batch(
-			StatusCmd(m.conn),       // decide conditionally in your impl
-			FetchLibraryCmd(m.conn), // decide conditionally in your impl
-			IdleCmd(m.conn, []string{"player", "database"}),
-		)
+		cmds := []tea.Cmd{IdleCmd(m.idleConn, idleSubs)}
+		status := false
+		for _, sub := range msg.Subs {
+			switch sub {
+			case "player", "mixer", "options", "update":
+				status = true
+			case "database":
+				cmds = append(cmds, FetchLibraryCmd(m.conn))
+			case "output":
+				cmds = append(cmds, OutputsCmd(m.conn))
+			case "sticker":
+				cmds = append(cmds, LoadStickersCmd(m.conn))
+			}
+		}
+		if m.djOn && (slices.Contains(msg.Subs, "player") || slices.Contains(msg.Subs, "playlist")) {
+			cmds = append(cmds, AutoDJCmd(m.conn, m.dj, m.libSongs, m.stickers))
+		}
+		if status {
+			cmds = append(cmds, StatusCmd(m.conn))
+		}
+		return m, tea.Batch(cmds...)
 
 	case TickMsg:
-		if m.now.Playing {
+		if m.now.Playing && !m.seeking {
 			m.now.Elapsed += 500_000_000
 		}
-		return m, TickCmd(500_000_000) // 500ms
+		return m, tea.Batch(TickCmd(500_000_000), m.observePlay()) // 500ms
 
 	case ErrMsg:
+		if errors.Is(msg.Err, net.ErrClosed) {
+			return m, nil // a connection dropped by switchServer
+		}
 		m.lastErr = msg.Err
+		if msg.Err != nil && msg.Op != "" {
+			m.lastErr = fmt.Errorf("%s: %w", msg.Op, msg.Err)
+		}
+		return m, nil
+
+	case NoticeMsg:
+		m.notice = msg.Text
+		m.lastErr = nil
 		return m, nil
 
 	case tea.WindowSizeMsg:
 		m.width, m.height = msg.Width, msg.Height
 		return m, nil
 
-		// Key handling (add your preferred key lib later)
 	case tea.KeyMsg:
-		// Spacebar
-		if msg.Type == tea.KeySpace {
-			if m.conn != nil {
-				return m, PlaybackCmd(m.conn, PlayRequest{Action: ActionTogglePause})
+		if m.prompt.active() {
+			return m.updatePrompt(msg)
+		}
+		var act Action
+		act, m.pendingKeys = m.keys.resolve(m.pendingKeys, msg.String(), m.selecting)
+		m.keyPresses++
+		if len(m.pendingKeys) > 0 {
+			return m, KeySeqTimeoutCmd(m.keyPresses)
+		}
+		if act == "" {
+			return m, nil
+		}
+		return m.handleAction(act)
+
+	case KeySeqTimeoutMsg:
+		if msg.Press == m.keyPresses {
+			m.pendingKeys = nil // nothing pressed since
+		}
+		return m, nil
+
+	case tea.MouseMsg:
+		return m.updateMouse(msg)
+	}
+	return m, nil
+}
+
+func (m Model) handleAction(act Action) (tea.Model, tea.Cmd) {
+	if m.selecting {
+		if next, cmd, ok := m.selectAction(act); ok {
+			return next, cmd
+		}
+	}
+
+	switch act {
+	case ActTogglePause:
+		if m.conn != nil {
+			return m, PlaybackCmd(m.conn, PlayRequest{Action: ActionTogglePause})
+		}
+		return m, nil
+
+	case ActPlay, ActStop, ActNext, ActPrev:
+		if m.conn == nil {
+			return m, nil
+		}
+		req := PlayRequest{Action: map[Action]PlayAction{
+			ActPlay: ActionPlay, ActStop: ActionStop, ActNext: ActionNext, ActPrev: ActionPrev,
+		}[act]}
+		if act == ActPrev {
+			req.RestartAfter = m.deps.PrevRestart
+		}
+		return m, PlaybackCmd(m.conn, req)
+
+	case ActQuit:
+		return m, tea.Quit
+
+	case ActServers:
+		for i, t := range m.tabs {
+			if t.kind == TabServers {
+				return m.switchTab(i)
+			}
+		}
+		m.notice = "no servers to switch to; add [profiles.<name>] tables to the config"
+		return m, nil
+
+	case ActNextTab:
+		return m.switchTab((m.tabIdx + 1) % len(m.tabs))
+
+	case ActPrevTab:
+		return m.switchTab((m.tabIdx + len(m.tabs) - 1) % len(m.tabs))
+
+	case ActLyrics:
+		for i, t := range m.tabs {
+			if t.kind != TabLyrics {
+				continue
+			}
+			if i == m.tabIdx {
+				return m.switchTab(m.lyricsBack)
 			}
+			m.lyricsBack = m.tabIdx
+			return m.switchTab(i)
+		}
+		return m, nil
+
+	case ActRate0, ActRate1, ActRate2, ActRate3, ActRate4, ActRate5:
+		t, ok := m.cursorTrack()
+		if !ok {
+			m.notice = "not a track (use v to mark several)"
+			return m, nil
+		}
+		if m.conn == nil {
+			return m, nil
+		}
+		return m, RateCmd(m.conn, []string{t.URI}, int(act[len(act)-1]-'0'))
+
+	case ActFavourite:
+		t, ok := m.cursorTrack()
+		if !ok {
+			m.notice = "not a track (use v to mark several)"
+			return m, nil
+		}
+		if m.conn == nil {
+			return m, nil
+		}
+		return m, FavouriteCmd(m.conn, []string{t.URI}, !m.stickers[t.URI].Favourite)
+
+	case ActAutoDJ:
+		m.djOn = !m.djOn
+		if !m.djOn {
+			m.notice = "auto-DJ off"
+			return m, nil
+		}
+		cfg := m.dj.Config()
+		m.notice = fmt.Sprintf("auto-DJ on: %s, %d upcoming", cfg.Strategy, cfg.Upcoming)
+		if m.conn == nil {
 			return m, nil
 		}
+		return m, AutoDJCmd(m.conn, m.dj, m.libSongs, m.stickers)
+
+	case ActSaveSmart:
+		if m.tab == TabSmart && m.cursor < len(m.smart) {
+			m.prompt = prompt{kind: promptSmartSave, label: "save as playlist", value: m.smart[m.cursor].Source.Name}
+		}
+		return m, nil
+
+	case ActUpdateDB:
+		if m.conn != nil && m.now.UpdatingDB == 0 {
+			return m, UpdateDBCmd(m.conn)
+		}
+		return m, nil
+
+	case ActSelect:
+		m = m.clearSelection()
+		m.selecting = true
+		m.notice = ""
+		return m, nil
+
+	case ActSortCycle:
+		if m.tab == TabAll {
+			m = m.nextSortCol()
+		}
+		return m, nil
 
-		switch msg.String() {
-		case "q", "ctrl+c":
-			return m, tea.Quit
+	case ActSortReverse:
+		if m.tab == TabAll && m.sortCol != "" {
+			m.sortDesc = !m.sortDesc
+			m = m.resortAll()
+		}
+		return m, nil
 
-		case "tab":
-			if m.tab == TabAll {
-				m.tab = TabArtists
+	case ActUp:
+		if m.cursor > 0 {
+			m.cursor--
+		}
+		return m, nil
+
+	case ActDown:
+		if m.cursor+1 < m.listLen() {
+			m.cursor++
+		}
+		return m, nil
+
+	case ActTop:
+		m.cursor = 0
+		return m, nil
+
+	case ActBottom:
+		m.cursor = max(0, m.listLen()-1)
+		return m, nil
+
+	case ActBack:
+		switch m.tab {
+		case TabBrowse:
+			if n := len(m.browsePath); n > 0 {
+				m.browsePath = m.browsePath[:n-1]
+				m = m.refreshBrowse()
+				m.cursor = 0
+			}
+			return m, nil
+		case TabFolders:
+			if m.dir != "" && m.conn != nil {
+				return m, LsInfoCmd(m.conn, parentDir(m.dir))
+			}
+			return m, nil
+		}
+		if m.tab == TabArtists {
+			switch m.level {
+			case LevelTrack:
+				m.level = LevelAlbum
+				m.cursor = 0
+				m.tracks = nil
+			case LevelAlbum:
 				m.level = LevelArtist
 				m.cursor = 0
-			} else {
-				m.tab = TabAll
+				m.albums = nil
+				m.selectAlbum = ""
+			case LevelArtist:
+				// stay
+			}
+		}
+		return m, nil
+
+	case ActEnter:
+		if m.tab == TabAll {
+			// Play from All view (enqueue from cursor)
+			if m.conn != nil && len(m.allSongs) > 0 {
+				return m, EnqueueAllFromCursor(m.conn, m.allSongs, m.cursor)
+			}
+			return m, nil
+		}
+		if m.tab == TabBrowse {
+			if len(m.browseItems) > 0 {
+				m.browsePath = append(m.browsePath, m.browseItems[m.cursor])
+				m = m.refreshBrowse()
 				m.cursor = 0
+				return m, nil
+			}
+			if m.conn != nil && len(m.browseTracks) > 0 {
+				return m, EnqueueAllFromCursor(m.conn, m.browseTracks, m.cursor)
 			}
 			return m, nil
-
-		case "up", "k":
-			if m.cursor > 0 {
-				m.cursor--
+		}
+		if m.tab == TabSmart {
+			if ts := m.smartPick(m.cursor); m.conn != nil && len(ts) > 0 {
+				return m, EnqueueAllFromCursor(m.conn, ts, 0)
 			}
 			return m, nil
-
-		case "down", "j":
-			limit := 0
-			if m.tab == TabAll {
-				limit = len(m.allSongs)
-			} else { // artists tab
-				switch m.level {
-				case LevelArtist:
-					limit = len(m.artists)
-				case LevelAlbum:
-					limit = len(m.albums)
-				case LevelTrack:
-					limit = len(m.tracks)
-				}
+		}
+		if m.tab == TabHistory {
+			if m.conn != nil && m.cursor < len(m.history) {
+				return m, EnqueueAllFromCursor(m.conn, m.rowTracks(m.cursor), 0)
 			}
-			if m.cursor+1 < limit {
-				m.cursor++
-			}
-			return m, nil
-
-		case "backspace", "h":
-			if m.tab == TabArtists {
-				switch m.level {
-				case LevelTrack:
-					m.level = LevelAlbum
-					m.cursor = 0
-					m.tracks = nil
-				case LevelAlbum:
-					m.level = LevelArtist
-					m.cursor = 0
-					m.albums = nil
-					m.selectAlbum = ""
-				case LevelArtist:
-					// stay
-				}
+			return m, nil
+		}
+		if m.tab == TabServers {
+			if m.cursor >= len(m.deps.Profiles) {
+				return m, nil
+			}
+			p := m.deps.Profiles[m.cursor]
+			if p.Name == m.deps.Profile && m.connected {
+				m.notice = "already on " + p.Name
+				return m, nil
+			}
+			return m.switchServer(p)
+		}
+		if m.tab == TabPartitions {
+			if m.conn == nil || m.cursor >= len(m.partitions) {
+				return m, nil
+			}
+			if name := m.partitions[m.cursor]; name != m.partition() {
+				return m, PartitionCmd(m.conn, name)
 			}
 			return m, nil
-
-		case "enter":
-			if m.tab == TabAll {
-				// Play from All view (enqueue from cursor)
-				if m.conn != nil && len(m.allSongs) > 0 {
-					return m, EnqueueAllFromCursor(m.conn, m.allSongs, m.cursor)
+		}
+		if m.tab == TabOutputs {
+			if m.conn != nil && m.cursor < len(m.outputs) {
+				return m, ToggleOutputCmd(m.conn, m.outputs[m.cursor].ID)
+			}
+			return m, nil
+		}
+		if m.tab == TabFolders {
+			if m.cursor < len(m.dirs) {
+				if m.conn != nil {
+					return m, LsInfoCmd(m.conn, m.dirs[m.cursor])
 				}
 				return m, nil
 			}
-			// Artists tab
-			switch m.level {
-			case LevelArtist:
-				if len(m.artists) == 0 {
-					return m, nil
-				}
-				m.selectArtist = m.artists[m.cursor]
-				idx := buildIndexes(m.allSongs)
-				m.albums = idx.AlbumsByArtist[m.selectArtist]
-				m.level = LevelAlbum
-				m.cursor = 0
+			if m.conn != nil && len(m.dirTracks) > 0 {
+				return m, EnqueueAllFromCursor(m.conn, m.dirTracks, m.cursor-len(m.dirs))
+			}
+			return m, nil
+		}
+		// Artists tab
+		switch m.level {
+		case LevelArtist:
+			if len(m.artists) == 0 {
 				return m, nil
+			}
+			m.selectArtist = m.artists[m.cursor]
+			idx := buildIndexes(m.allSongs)
+			m.albums = idx.AlbumsByArtist[m.selectArtist]
+			m.level = LevelAlbum
+			m.cursor = 0
+			return m, nil
 
-			case LevelAlbum:
-				if len(m.albums) == 0 {
-					return m, nil
-				}
-				m.selectAlbum = m.albums[m.cursor]
-				idx := buildIndexes(m.allSongs)
-				m.tracks = idx.TracksByArtistAlbum[keyAA(m.selectArtist, m.selectAlbum)]
-				m.level = LevelTrack
-				m.cursor = 0
+		case LevelAlbum:
+			if len(m.albums) == 0 {
 				return m, nil
+			}
+			m.selectAlbum = m.albums[m.cursor]
+			idx := buildIndexes(m.allSongs)
+			m.tracks = idx.TracksByArtistAlbum[keyAA(m.selectArtist, m.selectAlbum)]
+			m.level = LevelTrack
+			m.cursor = 0
+			return m, nil
 
-			case LevelTrack:
-				// Play from album tracks (enqueue from cursor)
-				if m.conn != nil && len(m.tracks) > 0 {
-					return m, EnqueueAlbumFromCursor(m.conn, m.tracks, m.cursor)
-				}
-				return m, nil
+		case LevelTrack:
+			// Play from album tracks (enqueue from cursor)
+			if m.conn != nil && len(m.tracks) > 0 {
+				return m, EnqueueAlbumFromCursor(m.conn, m.tracks, m.cursor)
 			}
+			return m, nil
 		}
 	}
 	return m, nil
 }
+
+// Select mode actions; ok=false falls through to normal handling
+// (movement, tabs, drill-down).
+func (m Model) selectAction(act Action) (tea.Model, tea.Cmd, bool) {
+	switch act {
+	case ActMark:
+		m.toggleMark(m.cursor)
+		if m.cursor+1 < m.listLen() {
+			m.cursor++
+		}
+		return m, nil, true
+	case ActCancelSelect:
+		return m.clearSelection(), nil, true
+	case ActMarkRange:
+		if m.anchor < 0 {
+			m.anchor = m.cursor
+		} else {
+			m.markRange()
+			m.anchor = -1
+		}
+		return m, nil, true
+	case ActInvert:
+		m.invertMarks()
+		return m, nil, true
+	case ActMarkAll:
+		m.markAll()
+		return m, nil, true
+	case ActClearMarks:
+		m.marks = newMarkSet()
+		return m, nil, true
+	case ActMarkMatch:
+		m.prompt = prompt{kind: promptSearch, label: "mark matching"}
+		return m, nil, true
+	case ActAppend, ActPlayMarked, ActAddPlaylist, ActFavourite,
+		ActRate0, ActRate1, ActRate2, ActRate3, ActRate4, ActRate5:
+		uris := m.markedURIs()
+		if len(uris) == 0 {
+			if act == ActPlayMarked {
+				return m, nil, false // nothing marked: normal drill-down/play
+			}
+			m.notice = "nothing marked"
+			return m, nil, true
+		}
+		if act == ActAddPlaylist {
+			m.prompt = prompt{kind: promptPlaylist, label: "add to playlist"}
+			return m, nil, true
+		}
+		if m.conn == nil {
+			return m, nil, true
+		}
+		var cmd tea.Cmd
+		switch act {
+		case ActAppend:
+			cmd = QueueAppendCmd(m.conn, uris)
+		case ActPlayMarked:
+			cmd = EnqueueAndPlayCmd(m.conn, uris, 0)
+		case ActFavourite:
+			on := false // all favourites already: unfavourite them
+			for _, u := range uris {
+				on = on || !m.stickers[u].Favourite
+			}
+			cmd = FavouriteCmd(m.conn, uris, on)
+		default:
+			cmd = RateCmd(m.conn, uris, int(act[len(act)-1]-'0'))
+		}
+		return m.clearSelection(), cmd, true
+	}
+	return m, nil, false
+}
+
+func (m Model) updatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	kind, value := m.prompt.kind, m.prompt.value
+	next, submitted, done := m.prompt.update(msg)
+	m.prompt = next
+	if !done || !submitted {
+		return m, nil
+	}
+	switch kind {
+	case promptSearch:
+		n := m.markMatching(value)
+		m.notice = fmt.Sprintf("marked %d rows matching %q", n, value)
+	case promptPlaylist:
+		name := strings.TrimSpace(value)
+		if name == "" || m.conn == nil {
+			return m, nil
+		}
+		uris := m.markedURIs()
+		return m.clearSelection(), PlaylistAddCmd(m.conn, name, uris)
+	case promptSmartSave:
+		name := strings.TrimSpace(value)
+		if name == "" || m.conn == nil {
+			return m, nil
+		}
+		return m, SmartSaveCmd(m.conn, name, m.smartPick(m.cursor))
+	}
+	return m, nil
+}
+
+func (m Model) switchTab(i int) (tea.Model, tea.Cmd) {
+	m.tabIdx = i
+	m.tab = m.tabs[i].kind
+	m.cursor = 0
+	m.anchor = -1
+	switch m.tab {
+	case TabArtists:
+		m.level = LevelArtist
+	case TabBrowse:
+		m.browsePath = nil
+		m = m.refreshBrowse()
+	case TabFolders:
+		if m.conn != nil {
+			return m, LsInfoCmd(m.conn, m.dir)
+		}
+	case TabHistory:
+		if m.deps.History != "" {
+			return m, HistoryCmd(m.deps.History)
+		}
+	case TabOutputs:
+		if m.conn != nil {
+			return m, OutputsCmd(m.conn)
+		}
+	case TabPartitions:
+		if m.conn != nil {
+			return m, PartitionsCmd(m.conn)
+		}
+	}
+	return m, nil
+}
+
+// Number of rows in the list the cursor is moving through.
+func (m Model) listLen() int {
+	switch m.tab {
+	case TabAll:
+		return len(m.allSongs)
+	case TabArtists:
+		switch m.level {
+		case LevelArtist:
+			return len(m.artists)
+		case LevelAlbum:
+			return len(m.albums)
+		case LevelTrack:
+			return len(m.tracks)
+		}
+	case TabBrowse:
+		return len(m.browseItems) + len(m.browseTracks)
+	case TabFolders:
+		return len(m.dirs) + len(m.dirTracks)
+	case TabSmart:
+		return len(m.smart)
+	case TabHistory:
+		return len(m.history)
+	case TabOutputs:
+		return len(m.outputs)
+	case TabPartitions:
+		return len(m.partitions)
+	case TabServers:
+		return len(m.deps.Profiles)
+	case TabLyrics:
+		if !m.lyrics.Synced { // synced lyrics scroll themselves
+			return len(m.lyrics.Lines)
+		}
+	}
+	return 0
+}
+
+func parentDir(dir string) string {
+	if i := strings.LastIndex(dir, "/"); i >= 0 {
+		return dir[:i]
+	}
+	return ""
+}
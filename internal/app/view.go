@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/AJMerr/gompc/internal/mpd"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/muesli/reflow/truncate"
@@ -18,10 +19,11 @@ func (m Model) View() string {
 	b.WriteString(m.renderHeader() + "\n")
 
 	// Tabs
-	tabs := lipgloss.JoinHorizontal(lipgloss.Top,
-		tabLabelStyled(s, m.tab == TabAll, "All"),
-		tabLabelStyled(s, m.tab == TabArtists, "Artists"),
-	)
+	labels := make([]string, len(m.tabs))
+	for i, t := range m.tabs {
+		labels[i] = tabLabelStyled(s, i == m.tabIdx, t.label)
+	}
+	tabs := lipgloss.JoinHorizontal(lipgloss.Top, labels...)
 	b.WriteString(tabs + "\n")
 
 	// Content
@@ -31,6 +33,22 @@ func (m Model) View() string {
 		content = listAllViewStyled(m)
 	case TabArtists:
 		content = artistsViewStyled(m)
+	case TabBrowse:
+		content = browseViewStyled(m)
+	case TabFolders:
+		content = folderViewStyled(m)
+	case TabSmart:
+		content = smartViewStyled(m)
+	case TabHistory:
+		content = historyViewStyled(m)
+	case TabLyrics:
+		content = lyricsViewStyled(m)
+	case TabOutputs:
+		content = outputsViewStyled(m)
+	case TabPartitions:
+		content = partitionsViewStyled(m)
+	case TabServers:
+		content = serversViewStyled(m)
 	}
 
 	// force panel to fill width
@@ -42,7 +60,17 @@ func (m Model) View() string {
 	if m.lastErr != nil {
 		b.WriteString("\n" + s.Error.Render(fmt.Sprintf("ERR: %v", m.lastErr)))
 	}
-	help := "↑/k ↓/j move • Enter play • Space pause • n/p next/prev • Tab switch • Backspace up • q quit"
+	if m.prompt.active() {
+		b.WriteString("\n" + s.Footer.Render(m.prompt.view(s)))
+		return b.String()
+	}
+	if m.notice != "" {
+		b.WriteString("\n" + s.Footer.Render(fitTo(m.width, m.notice)))
+	}
+	help := m.keys.help(m.selecting)
+	if m.selecting {
+		help = s.HeaderBadge.Render(fmt.Sprintf("SELECT %d", m.marks.len())) + " " + help
+	}
 	b.WriteString("\n" + s.Footer.Render(fitTo(m.width, help)))
 
 	return b.String()
@@ -61,28 +89,23 @@ func listAllViewStyled(m Model) string {
 		return s.ListRowDim.Render("(no tracks)")
 	}
 
-	rows := m.maxRowsForList()
-	start, end := windowAroundCursor(m.cursor, rows, len(m.allSongs))
-
 	pfw, _ := s.Panel.GetFrameSize()
 	cw := max(20, m.width-pfw)
 	rowPad := lipgloss.NewStyle().Width(cw)
+	cols, widths := layoutColumns(m.columns, cw-4) // gutter and panel padding
+
+	rows := m.maxRowsForList() - 1 // header row
+	start, end := windowAroundCursor(m.cursor, rows, len(m.allSongs))
 
 	var b strings.Builder
+	b.WriteString(rowPad.Render(s.Breadcrumb.Bold(true).Render(fitTo(cw, m.tableHeader(cols, widths)))) + "\n")
 	for i := start; i < end; i++ {
-		t := m.allSongs[i]
-		cur := "  "
+		cur := m.gutter(i)
 		rowStyle := s.ListRow
 		if i == m.cursor {
-			cur = s.Cursor.Render("▍") + " "
 			rowStyle = rowStyle.Bold(true)
 		}
-		artist := nz(t.Artist, "<unknown>")
-		title := t.Title
-		if title == "" {
-			title = baseNameFromURI(t.URI)
-		}
-		line := fmt.Sprintf("%s%s — %s [%s]", cur, artist, title, t.Album)
+		line := cur + tableRow(m.allSongs[i], m.stickers[m.allSongs[i].URI], cols, widths)
 		b.WriteString(rowPad.Render(rowStyle.Render(fitTo(cw, line))) + "\n")
 	}
 	if end < len(m.allSongs) {
@@ -118,10 +141,9 @@ func artistsViewStyled(m Model) string {
 			}
 			start, end := windowAroundCursor(m.cursor, rows, len(m.artists))
 			for i := start; i < end; i++ {
-				cur := "  "
+				cur := m.gutter(i)
 				rowStyle := s.ListRow
 				if i == m.cursor {
-					cur = s.Cursor.Render("▍") + " "
 					rowStyle = rowStyle.Bold(true)
 				}
 				b.WriteString(rowPad.Render(rowStyle.Render(fitTo(cw, cur+m.artists[i]))) + "\n")
@@ -134,10 +156,9 @@ func artistsViewStyled(m Model) string {
 			}
 			start, end := windowAroundCursor(m.cursor, rows, len(m.albums))
 			for i := start; i < end; i++ {
-				cur := "  "
+				cur := m.gutter(i)
 				rowStyle := s.ListRow
 				if i == m.cursor {
-					cur = s.Cursor.Render("▍") + " "
 					rowStyle = rowStyle.Bold(true)
 				}
 				b.WriteString(rowPad.Render(rowStyle.Render(fitTo(cw, cur+m.albums[i]))) + "\n")
@@ -151,10 +172,9 @@ func artistsViewStyled(m Model) string {
 			start, end := windowAroundCursor(m.cursor, rows, len(m.tracks))
 			for i := start; i < end; i++ {
 				t := m.tracks[i]
-				cur := "  "
+				cur := m.gutter(i)
 				rowStyle := s.ListRow
 				if i == m.cursor {
-					cur = s.Cursor.Render("▍") + " "
 					rowStyle = rowStyle.Bold(true)
 				}
 				title := t.Title
@@ -195,10 +215,9 @@ func artistsViewStyled(m Model) string {
 		}
 		lstart, lend := windowAroundCursor(m.cursor, rows, len(m.artists))
 		for i := lstart; i < lend; i++ {
-			cur := "  "
+			cur := m.gutter(i)
 			row := s.ListRow
 			if i == m.cursor {
-				cur = s.Cursor.Render("▍") + " "
 				row = row.Bold(true)
 			}
 			left.WriteString(leftPad.Render(row.Render(fitTo(leftW, cur+m.artists[i]))) + "\n")
@@ -228,10 +247,9 @@ func artistsViewStyled(m Model) string {
 		}
 		lstart, lend := windowAroundCursor(m.cursor, rows, len(m.albums))
 		for i := lstart; i < lend; i++ {
-			cur := "  "
+			cur := m.gutter(i)
 			row := s.ListRow
 			if i == m.cursor {
-				cur = s.Cursor.Render("▍") + " "
 				row = row.Bold(true)
 			}
 			left.WriteString(leftPad.Render(row.Render(fitTo(leftW, cur+m.albums[i]))) + "\n")
@@ -286,10 +304,9 @@ func artistsViewStyled(m Model) string {
 			rstart, rend := windowAroundCursor(m.cursor, rows, len(m.tracks))
 			for i := rstart; i < rend; i++ {
 				t := m.tracks[i]
-				cur := "  "
+				cur := m.gutter(i)
 				row := s.ListRow
 				if i == m.cursor {
-					cur = s.Cursor.Render("▍") + " "
 					row = row.Bold(true)
 				}
 				title := t.Title
@@ -317,6 +334,80 @@ func artistsViewStyled(m Model) string {
 	)
 }
 
+func browseViewStyled(m Model) string {
+	s := m.styles
+	crumb := s.Breadcrumb.Render(m.browseCrumb()) + "\n"
+	if m.browseItems != nil || len(m.browseTracks) == 0 {
+		return crumb + plainListStyled(m, m.browseItems, "(nothing here)")
+	}
+	labels := make([]string, len(m.browseTracks))
+	for i, t := range m.browseTracks {
+		labels[i] = trackLabel(s, t)
+	}
+	return crumb + plainListStyled(m, labels, "(no tracks)")
+}
+
+func folderViewStyled(m Model) string {
+	s := m.styles
+	crumb := s.Breadcrumb.Render(m.folderCrumb()) + "\n"
+	return crumb + plainListStyled(m, m.folderLabels(), "(empty folder)")
+}
+
+func outputsViewStyled(m Model) string {
+	s := m.styles
+	crumb := s.Breadcrumb.Render("Outputs › Enter toggles") + "\n"
+	labels := make([]string, len(m.outputs))
+	for i, o := range m.outputs {
+		box := "[ ] "
+		if o.Enabled {
+			box = "[x] "
+		}
+		labels[i] = box + o.Name + s.ListRowDim.Render(" ("+o.Plugin+")")
+	}
+	return crumb + plainListStyled(m, labels, "(no outputs)")
+}
+
+// Single-column cursor list used by the browse and folder tabs.
+func plainListStyled(m Model, labels []string, empty string) string {
+	s := m.styles
+	if len(labels) == 0 {
+		return s.ListRowDim.Render(empty)
+	}
+	pfw, _ := s.Panel.GetFrameSize()
+	cw := max(20, m.width-pfw)
+	rowPad := lipgloss.NewStyle().Width(cw)
+	start, end := windowAroundCursor(m.cursor, m.maxRowsForList(), len(labels))
+
+	var b strings.Builder
+	for i := start; i < end; i++ {
+		cur := m.gutter(i)
+		rowStyle := s.ListRow
+		if i == m.cursor {
+			rowStyle = rowStyle.Bold(true)
+		}
+		b.WriteString(rowPad.Render(rowStyle.Render(fitTo(cw, cur+labels[i]))) + "\n")
+	}
+	return b.String()
+}
+
+// "[d.tt] Artist — Title" as shown in album track lists.
+func trackLabel(s Styles, t mpd.Track) string {
+	title := t.Title
+	if title == "" {
+		title = baseNameFromURI(t.URI)
+	}
+	prefix := ""
+	if t.DiscNo > 0 || t.TrackNo > 0 {
+		if t.DiscNo > 0 {
+			prefix = fmt.Sprintf("[%d.%02d] ", t.DiscNo, t.TrackNo)
+		} else {
+			prefix = fmt.Sprintf("[%02d] ", t.TrackNo)
+		}
+		prefix = s.ListRowDim.Render(prefix)
+	}
+	return prefix + nz(t.Artist, "<unknown>") + " — " + title
+}
+
 // Progress Bar
 func (m Model) renderProgress(width int) string {
 	if m.now.Duration <= 0 {
@@ -342,6 +433,28 @@ func (m Model) renderProgress(width int) string {
 }
 
 func (m Model) renderHeader() string {
+	box, progress := m.headerBlocks()
+	if progress == "" {
+		return box
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, box, "  ", progress)
+}
+
+// Width of the progress bar for the current terminal width.
+func (m Model) barWidth() int {
+	switch w := m.width; {
+	case w > 0 && w < 50:
+		return 8
+	case w > 0 && w < 100:
+		return 16
+	default:
+		return clamp(w/4, 16, 30)
+	}
+}
+
+// Header box (title, connection, now playing) and the progress block that
+// sits to its right while a song is loaded.
+func (m Model) headerBlocks() (box, progress string) {
 	s := m.styles
 	state := "disconnected"
 	if m.connected {
@@ -351,40 +464,66 @@ func (m Model) renderHeader() string {
 	badge := s.HeaderBadge.Render(state)
 
 	w := m.width
-	nowFull := fmt.Sprintf("%s — %s [%s]", nz(m.now.Artist, "<unknown>"), nz(m.now.Title, "<untitled>"), nz(m.now.Album, "<unknown>"))
-	nowCompact := fmt.Sprintf("%s — %s", nz(m.now.Artist, "<unknown>"), nz(m.now.Title, "<untitled>"))
+	glyph := stateGlyph(m.now.State)
+	nowFull := fmt.Sprintf("%s%s — %s [%s]", glyph, nz(m.now.Artist, "<unknown>"), nz(m.now.Title, "<untitled>"), nz(m.now.Album, "<unknown>"))
+	nowCompact := fmt.Sprintf("%s%s — %s", glyph, nz(m.now.Artist, "<unknown>"), nz(m.now.Title, "<untitled>"))
 
-	var nowShown string
-	var barWidth int
-	switch {
-	case w > 0 && w < 50:
+	nowShown := nowFull
+	if w > 0 && w < 80 {
 		nowShown = nowCompact
-		barWidth = 8
-	case w > 0 && w < 80:
-		nowShown = nowCompact
-		barWidth = 16
-	default:
-		nowShown = nowFull
-		barWidth = clamp(w/3, 12, 30)
 	}
 
+	if m.now.Duration > 0 {
+		progress = m.renderProgress(m.barWidth())
+	}
 	hfw, _ := s.Header.GetFrameSize()
 	headerW := max(10, w-hfw)
-	nowStr := s.HeaderNow.Render(fitTo(headerW/2, nowShown))
-
-	header := lipgloss.JoinHorizontal(lipgloss.Top,
-		title,
-		lipgloss.NewStyle().Foreground(colMuted).Render(" • MPD: "),
-		badge,
-		lipgloss.NewStyle().Render(" • "),
-		nowStr,
-	)
+	if progress != "" {
+		headerW = max(10, headerW-lipgloss.Width(progress)-2)
+	}
 
-	out := s.Header.Width(headerW).Render(header)
-	if m.now.Duration > 0 {
-		out += "  " + m.renderProgress(barWidth)
+	server := "MPD"
+	if m.deps.Profile != "" {
+		server = m.deps.Profile
+	}
+	if p := m.partition(); p != "default" {
+		server += " › " + p
+	}
+	parts := []string{title, s.HeaderSep.Render(" • " + server + ": "), badge}
+	if m.now.UpdatingDB > 0 {
+		parts = append(parts, " ", s.HeaderBadge.Render(m.updateBadge()))
+	}
+	if m.djOn {
+		parts = append(parts, " ", s.HeaderBadge.Render("DJ"))
+	}
+	parts = append(parts, lipgloss.NewStyle().Render(" • "))
+	prefix := lipgloss.JoinHorizontal(lipgloss.Top, parts...)
+	inner := headerW - s.Header.GetHorizontalPadding()
+	nowStr := s.HeaderNow.Render(fitTo(max(8, inner-lipgloss.Width(prefix)), nowShown))
+
+	header := lipgloss.JoinHorizontal(lipgloss.Top, prefix, nowStr)
+	return s.Header.Width(headerW).Render(header), progress
+}
+
+// "⟳ db update 12s" while MPD is updating the database
+func (m Model) updateBadge() string {
+	text := "⟳ db update"
+	if !m.updateStart.IsZero() {
+		text += " " + time.Since(m.updateStart).Truncate(time.Second).String()
+	}
+	return text
+}
+
+func stateGlyph(state string) string {
+	switch state {
+	case "play":
+		return "▶ "
+	case "pause":
+		return "⏸ "
+	case "stop":
+		return "■ "
 	}
-	return out
+	return ""
 }
 
 func truncDur(d time.Duration) string {
@@ -0,0 +1,62 @@
+// Package art keeps covers fetched from MPD on disk, for notifications and
+// MPRIS clients, which want a file rather than bytes.
+package art
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+)
+
+// Cache stores one cover per song directory. The zero value caches
+// nothing.
+type Cache struct {
+	Dir string
+}
+
+// The cover file for uri, fetched from MPD the first time. "" when the song
+// has none, is a stream, or there's no Dir.
+func (c Cache) Path(ctx context.Context, conn mpd.Conn, uri string) string {
+	if c.Dir == "" || uri == "" || strings.Contains(uri, "://") {
+		return ""
+	}
+	sum := sha1.Sum([]byte(path.Dir(uri)))
+	file := filepath.Join(c.Dir, hex.EncodeToString(sum[:]))
+	if _, err := os.Stat(file); err == nil {
+		return file
+	}
+	data, err := conn.AlbumArt(ctx, uri) // ErrNoArt and failures alike
+	if err != nil {
+		return ""
+	}
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return ""
+	}
+	// write then rename, so a reader never sees half a file; the temp
+	// name is unique, so concurrent fetches of one album don't collide
+	f, err := os.CreateTemp(c.Dir, "cover-*")
+	if err != nil {
+		return ""
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), file)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return ""
+	}
+	return file
+}
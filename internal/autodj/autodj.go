@@ -0,0 +1,232 @@
+// Package autodj keeps the queue topped up with songs picked from the
+// library, so playback doesn't stop when the queue runs out.
+package autodj
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+	"github.com/AJMerr/gompc/internal/stickers"
+)
+
+// How the next song relates to the one before it.
+type Strategy string
+
+const (
+	Random Strategy = "random"
+	Artist Strategy = "artist" // same artist
+	Genre  Strategy = "genre"  // same genre
+	Decade Strategy = "decade" // same decade
+)
+
+var Strategies = []Strategy{Random, Artist, Genre, Decade}
+
+func ParseStrategy(s string) (Strategy, error) {
+	for _, st := range Strategies {
+		if strings.EqualFold(s, string(st)) {
+			return st, nil
+		}
+	}
+	return "", fmt.Errorf("unknown auto-DJ strategy %q (want random, artist, genre or decade)", s)
+}
+
+type Config struct {
+	Upcoming int // songs to keep queued after the current one
+	Strategy Strategy
+	Weighted bool          // prefer higher-rated and favourite songs
+	Avoid    time.Duration // skip songs played this recently (0 = don't)
+}
+
+// Whether picks read stickers (ratings or last-played times).
+func (c Config) NeedsStickers() bool { return c.Weighted || c.Avoid > 0 }
+
+// DJ remembers its recent picks between top-ups. Safe for concurrent use;
+// top-ups run one at a time.
+type DJ struct {
+	cfg Config
+
+	mu        sync.Mutex
+	recent    []string // last picks, oldest first
+	lastState string
+}
+
+// Picks kept in memory to avoid repeats within a session
+const recentMax = 200
+
+func New(cfg Config) *DJ {
+	if cfg.Upcoming <= 0 {
+		cfg.Upcoming = 5
+	}
+	if cfg.Strategy == "" {
+		cfg.Strategy = Random
+	}
+	return &DJ{cfg: cfg}
+}
+
+func (dj *DJ) Config() Config { return dj.cfg }
+
+// Queues songs from lib until Upcoming songs follow the current one. When
+// the queue ran out while playing, playback resumes with the first pick.
+// db may be nil. Does nothing while repeat is on; the queue never ends.
+// With random on, queue positions say nothing about what is left, so every
+// queued song but the current one counts as upcoming.
+func (dj *DJ) TopUp(ctx context.Context, conn mpd.Conn, lib []mpd.Track, db stickers.DB) ([]mpd.Track, error) {
+	dj.mu.Lock()
+	defer dj.mu.Unlock()
+
+	st, err := conn.PlayerStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ranOut := dj.lastState == "play" && st.State == "stop" && st.Song < 0
+	dj.lastState = st.State
+	if st.Repeat {
+		return nil, nil
+	}
+	queue, err := conn.QueueList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	upcoming := len(queue)
+	switch {
+	case ranOut:
+		upcoming = 0
+	case st.Random && st.Song >= 0:
+		upcoming = len(queue) - 1 // shuffled: any song may still come
+	case st.Song >= 0:
+		upcoming = len(queue) - st.Song - 1
+	}
+	need := dj.cfg.Upcoming - upcoming
+	if need <= 0 {
+		return nil, nil
+	}
+
+	var seed mpd.Track
+	if len(queue) > 0 {
+		seed = queue[len(queue)-1]
+	}
+	exclude := make(map[string]bool, len(queue))
+	for _, t := range queue {
+		exclude[t.URI] = true
+	}
+	now := time.Now()
+	var added []mpd.Track
+	for range need {
+		t, ok := dj.pick(lib, db, seed, exclude, now)
+		if !ok {
+			break
+		}
+		if err := conn.QueueAdd(ctx, t.URI); err != nil {
+			return added, err
+		}
+		added = append(added, t)
+		exclude[t.URI] = true
+		dj.remember(t.URI)
+		seed = t
+	}
+	if ranOut && len(added) > 0 {
+		if err := conn.PlayPos(ctx, len(queue)); err != nil {
+			return added, err
+		}
+		dj.lastState = "play"
+	}
+	return added, nil
+}
+
+func (dj *DJ) remember(uri string) {
+	dj.recent = append(dj.recent, uri)
+	if len(dj.recent) > recentMax {
+		dj.recent = dj.recent[len(dj.recent)-recentMax:]
+	}
+}
+
+// Chooses the next song after seed. The strategy and repeat avoidance are
+// relaxed, in that order, when they leave nothing to pick from.
+func (dj *DJ) pick(lib []mpd.Track, db stickers.DB, seed mpd.Track, exclude map[string]bool, now time.Time) (mpd.Track, bool) {
+	recent := make(map[string]bool, len(dj.recent))
+	for _, u := range dj.recent {
+		recent[u] = true
+	}
+	fresh := func(t mpd.Track) bool {
+		if recent[t.URI] {
+			return false
+		}
+		lp := db[t.URI].LastPlayed
+		return dj.cfg.Avoid <= 0 || lp.IsZero() || now.Sub(lp) > dj.cfg.Avoid
+	}
+	similar := func(t mpd.Track) bool { return dj.similar(seed, t) }
+
+	for _, filters := range [][]func(mpd.Track) bool{
+		{fresh, similar},
+		{fresh},
+		{},
+	} {
+		var cands []mpd.Track
+	next:
+		for _, t := range lib {
+			if exclude[t.URI] {
+				continue
+			}
+			for _, f := range filters {
+				if !f(t) {
+					continue next
+				}
+			}
+			cands = append(cands, t)
+		}
+		if len(cands) > 0 {
+			return dj.choose(cands, db), true
+		}
+	}
+	return mpd.Track{}, false
+}
+
+// Whether t matches seed under the strategy; anything goes without a seed.
+func (dj *DJ) similar(seed, t mpd.Track) bool {
+	if seed.URI == "" {
+		return true
+	}
+	switch dj.cfg.Strategy {
+	case Artist:
+		return seed.Artist != "" && strings.EqualFold(t.Artist, seed.Artist)
+	case Genre:
+		return seed.Genre != "" && strings.EqualFold(t.Genre, seed.Genre)
+	case Decade:
+		return seed.Year > 0 && t.Year/10 == seed.Year/10
+	}
+	return true
+}
+
+// Weighted pick: unrated songs count as three stars, favourites double.
+func (dj *DJ) choose(cands []mpd.Track, db stickers.DB) mpd.Track {
+	if !dj.cfg.Weighted {
+		return cands[rand.IntN(len(cands))]
+	}
+	weights := make([]int, len(cands))
+	total := 0
+	for i, t := range cands {
+		s := db[t.URI]
+		w := s.Rating
+		if w == 0 {
+			w = 3
+		}
+		if s.Favourite {
+			w *= 2
+		}
+		weights[i] = w
+		total += w
+	}
+	n := rand.IntN(total)
+	for i, w := range weights {
+		if n < w {
+			return cands[i]
+		}
+		n -= w
+	}
+	return cands[len(cands)-1]
+}
@@ -10,6 +10,8 @@ import (
 	"os"
 	"strings"
 	"time"
+
+	"github.com/AJMerr/gompc/internal/mpd"
 )
 
 type Config struct {
@@ -118,20 +120,20 @@ func Run(ctx context.Context, cfg Config, deep bool) Report {
 	}
 
 	// Connection and greeating
-	mpd, ver, d, err := dial(ctx, addr, timeout)
+	conn, ver, d, err := dial(ctx, addr, timeout)
 	if err != nil {
 		rep.Checks = append(rep.Checks, Check{"tcp_connect", false, false, ms(d), fmt.Sprintf("connect %s failed %v", addr, err)})
 		rep.Result = "FAIL(connect)"
 		rep.ExitCode = ExitNoConnect
 		return rep
 	}
-	defer mpd.Close()
+	defer conn.Close()
 	rep.Checks = append(rep.Checks, Check{"tcp_connect", true, false, ms(d), "connected"})
 	rep.MPDVersion = ver
 	rep.Checks = append(rep.Checks, Check{"greeting", true, false, 0, "OK MPD " + ver})
 
 	// Status
-	if lines, dur, err := mpd.cmd(timeout, "status"); err != nil {
+	if lines, dur, err := conn.cmd(timeout, "status"); err != nil {
 		code := ExitCmdFailed
 		if strings.Contains(err.Error(), "permission denied") {
 			code = ExitAuthFailed
@@ -153,7 +155,7 @@ func Run(ctx context.Context, cfg Config, deep bool) Report {
 
 	// Stats
 	var songs = "unknown"
-	if lines, dur, err := mpd.cmd(timeout, "stats"); err != nil {
+	if lines, dur, err := conn.cmd(timeout, "stats"); err != nil {
 		rep.Checks = append(rep.Checks, Check{"stats", false, false, ms(dur), err.Error()})
 		rep.Result = "FAIL(stats)"
 		rep.ExitCode = ExitCmdFailed
@@ -168,13 +170,13 @@ func Run(ctx context.Context, cfg Config, deep bool) Report {
 		warn := songs == "0"
 		msg := "songs=" + songs
 		if warn {
-			msg += " (library empty? run mpc update)"
+			msg += " (library empty? run gompc update)"
 		}
 		rep.Checks = append(rep.Checks, Check{"stats", true, warn, ms(dur), msg})
 	}
 
 	// Outputs
-	if lines, dur, err := mpd.cmd(timeout, "outputs"); err != nil {
+	if lines, dur, err := conn.cmd(timeout, "outputs"); err != nil {
 		rep.Checks = append(rep.Checks, Check{"outputs", false, false, ms(dur), err.Error()})
 		rep.Result = "FAIL(outputs)"
 		rep.ExitCode = ExitCmdFailed
@@ -192,21 +194,48 @@ func Run(ctx context.Context, cfg Config, deep bool) Report {
 		warn := total == 0 || enabled == 0
 		msg := fmt.Sprintf("output=%d enabled=%d", total, enabled)
 		if warn {
-			msg += " (enabled with 'mpc enable <id>')"
+			msg += " (enable with 'gompc outputs enable <id>')"
 		}
 		rep.Checks = append(rep.Checks, Check{"output", true, warn, ms(dur), msg})
 	}
 
+	// Mounts; listfiles reads the storage itself, so a dead share fails
+	// even though its songs are still in the database
+	if lines, dur, err := conn.cmd(timeout, "listmounts"); err != nil {
+		rep.Checks = append(rep.Checks, Check{"mounts", true, false, ms(dur), "unavailable: " + err.Error()})
+	} else {
+		mounts := mpd.ParseMounts(lines)
+		var list, down []string
+		for _, m := range mounts {
+			if m.Point == "" {
+				list = append(list, "/ ("+m.Storage+")")
+				continue
+			}
+			list = append(list, m.Point+" ("+m.Storage+")")
+			if _, _, err := conn.cmd(timeout, "listfiles "+mpd.Quote(m.Point)); err != nil {
+				down = append(down, m.Point)
+			}
+		}
+		msg := fmt.Sprintf("mounts=%d", len(mounts))
+		if len(list) > 0 {
+			msg += ": " + strings.Join(list, ", ")
+		}
+		if len(down) > 0 {
+			msg += "; unreachable: " + strings.Join(down, ", ") + " (check the share or 'gompc mounts remove')"
+		}
+		rep.Checks = append(rep.Checks, Check{"mounts", true, len(down) > 0, ms(dur), msg})
+	}
+
 	// Deep
 	if deep {
-		if _, dur, err := mpd.cmd(timeout, "idle player database"); err != nil {
-			_, _, _ = mpd.cmd(timeout, "noidle")
+		if _, dur, err := conn.cmd(timeout, "idle player database"); err != nil {
+			_, _, _ = conn.cmd(timeout, "noidle")
 			rep.Checks = append(rep.Checks, Check{"idle_roundtrip", false, false, ms(dur), "idle failed (try again, or skip --deep)"})
 			rep.Result = "FAIL(deep)"
 			rep.ExitCode = ExitDeepFailed
 			return rep
 		}
-		_, _, _ = mpd.cmd(timeout, "noidle")
+		_, _, _ = conn.cmd(timeout, "noidle")
 		rep.Checks = append(rep.Checks, Check{"idle_roundtrip", true, false, 0, "idle/noidle OK"})
 	}
 
@@ -223,14 +252,18 @@ func RenderHuman(cfg Config, cfgPath string, rep Report) {
 		fmt.Printf("Doctor: mpd=%s:%d timeout=%dms\n", cfg.Host, cfg.Port, cfg.TimeoutMS)
 	}
 	for _, c := range rep.Checks {
-		icon := "✓"
-		if !c.OK {
-			icon = "✗"
-		} else if c.Warning {
-			icon = "⚠"
-		}
-		fmt.Printf("%s %-15s %s (%dms)\n", icon, c.Name, c.Message, c.Duration)
+		RenderCheck(c)
+	}
+}
+
+func RenderCheck(c Check) {
+	icon := "✓"
+	if !c.OK {
+		icon = "✗"
+	} else if c.Warning {
+		icon = "⚠"
 	}
+	fmt.Printf("%s %-15s %s (%dms)\n", icon, c.Name, c.Message, c.Duration)
 }
 
 func RenderJSON(rep Report) {
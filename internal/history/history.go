@@ -0,0 +1,94 @@
+// Package history keeps a local log of what was played, one JSON object
+// per line, and summarises it for `gompc stats`.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/AJMerr/gompc/internal/plays"
+)
+
+// One finished or skipped song.
+type Entry struct {
+	Ended      time.Time `json:"ended"`
+	Started    time.Time `json:"started"`
+	URI        string    `json:"uri"`
+	Title      string    `json:"title,omitempty"`
+	Artist     string    `json:"artist,omitempty"`
+	Album      string    `json:"album,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	PlayedMS   int64     `json:"played_ms"` // furthest position reached
+	Skipped    bool      `json:"skipped,omitempty"`
+}
+
+func (e Entry) Duration() time.Duration { return time.Duration(e.DurationMS) * time.Millisecond }
+func (e Entry) Played() time.Duration   { return time.Duration(e.PlayedMS) * time.Millisecond }
+
+// The entry for p, or false for plays worth no entry: streams without a
+// length, and songs joined late that ran out.
+func FromPlay(p plays.Play) (Entry, bool) {
+	if !p.Finished && !p.Skipped {
+		return Entry{}, false
+	}
+	return Entry{
+		Ended:      p.Ended.Truncate(time.Second),
+		Started:    p.Started.Truncate(time.Second),
+		URI:        p.Song.URI,
+		Title:      p.Song.Title,
+		Artist:     p.Song.Artist,
+		Album:      p.Song.Album,
+		DurationMS: p.Song.Duration.Milliseconds(),
+		PlayedMS:   p.Played.Milliseconds(),
+		Skipped:    p.Skipped,
+	}, true
+}
+
+// Appends e to the log at path, creating it if needed.
+func Append(path string, e Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Reads the log, oldest first. A missing file is an empty history; lines
+// that don't parse (a torn last write) are skipped.
+func Read(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var e Entry
+		if json.Unmarshal(sc.Bytes(), &e) == nil && e.URI != "" {
+			out = append(out, e)
+		}
+	}
+	return out, sc.Err()
+}
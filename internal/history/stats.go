@@ -0,0 +1,189 @@
+package history
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Plays of one artist, album or track.
+type Count struct {
+	Name     string        `json:"name"`
+	Artist   string        `json:"artist,omitempty"` // albums and tracks
+	Plays    int           `json:"plays"`            // not counting skips
+	Skips    int           `json:"skips"`
+	Listened time.Duration `json:"-"`
+	SkipRate float64       `json:"skip_rate"`
+
+	ListenedMS int64 `json:"listened_ms"`
+}
+
+// Listening time in one day or week.
+type Bucket struct {
+	Start    time.Time     `json:"start"`
+	Label    string        `json:"label"` // "2026-10-18" or "2026-W42"
+	Plays    int           `json:"plays"`
+	Listened time.Duration `json:"-"`
+
+	ListenedMS int64 `json:"listened_ms"`
+}
+
+type Report struct {
+	From     time.Time     `json:"from"`
+	To       time.Time     `json:"to"`
+	Plays    int           `json:"plays"`
+	Skips    int           `json:"skips"`
+	SkipRate float64       `json:"skip_rate"`
+	Listened time.Duration `json:"-"`
+
+	ListenedMS int64 `json:"listened_ms"`
+
+	TopArtists []Count  `json:"top_artists"`
+	TopAlbums  []Count  `json:"top_albums"`
+	TopTracks  []Count  `json:"top_tracks"`
+	MostSkips  []Count  `json:"most_skipped"`
+	Days       []Bucket `json:"days"`
+	Weeks      []Bucket `json:"weeks"`
+}
+
+// Summarises entries ended at or after since (zero = all), keeping top
+// lists of n. Days and weeks are in loc, newest first.
+func Summarize(entries []Entry, since time.Time, n int, loc *time.Location) Report {
+	var r Report
+	artists := map[string]*Count{}
+	albums := map[string]*Count{}
+	tracks := map[string]*Count{}
+	days := map[string]*Bucket{}
+	weeks := map[string]*Bucket{}
+
+	count := func(m map[string]*Count, key, name, artist string, e Entry) {
+		c := m[key]
+		if c == nil {
+			c = &Count{Name: name, Artist: artist}
+			m[key] = c
+		}
+		if e.Skipped {
+			c.Skips++
+		} else {
+			c.Plays++
+		}
+		c.Listened += e.Played()
+	}
+	bucket := func(m map[string]*Bucket, label string, start time.Time, e Entry) {
+		b := m[label]
+		if b == nil {
+			b = &Bucket{Start: start, Label: label}
+			m[label] = b
+		}
+		if !e.Skipped {
+			b.Plays++
+		}
+		b.Listened += e.Played()
+	}
+
+	for _, e := range entries {
+		if e.Ended.Before(since) {
+			continue
+		}
+		if r.From.IsZero() || e.Ended.Before(r.From) {
+			r.From = e.Ended
+		}
+		if e.Ended.After(r.To) {
+			r.To = e.Ended
+		}
+		if e.Skipped {
+			r.Skips++
+		} else {
+			r.Plays++
+		}
+		r.Listened += e.Played()
+
+		artist := nz(e.Artist, "<unknown>")
+		count(artists, strings.ToLower(artist), artist, "", e)
+		if e.Album != "" {
+			count(albums, strings.ToLower(artist+"\x00"+e.Album), e.Album, artist, e)
+		}
+		count(tracks, e.URI, nz(e.Title, e.URI), artist, e)
+
+		t := e.Ended.In(loc)
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		bucket(days, day.Format("2006-01-02"), day, e)
+		y, w := t.ISOWeek()
+		monday := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		bucket(weeks, fmt.Sprintf("%d-W%02d", y, w), monday, e)
+	}
+	r.SkipRate = rate(r.Skips, r.Plays)
+	r.ListenedMS = r.Listened.Milliseconds()
+	r.TopArtists = top(artists, n, byPlays)
+	r.TopAlbums = top(albums, n, byPlays)
+	r.TopTracks = top(tracks, n, byPlays)
+	r.MostSkips = []Count{}
+	for _, c := range top(artists, n, bySkips) {
+		if c.Skips > 0 {
+			r.MostSkips = append(r.MostSkips, c)
+		}
+	}
+	r.Days = buckets(days)
+	r.Weeks = buckets(weeks)
+	return r
+}
+
+func byPlays(a, b *Count) bool {
+	if a.Plays != b.Plays {
+		return a.Plays > b.Plays
+	}
+	return a.Listened > b.Listened
+}
+
+func bySkips(a, b *Count) bool {
+	if a.Skips != b.Skips {
+		return a.Skips > b.Skips
+	}
+	return a.SkipRate > b.SkipRate
+}
+
+func top(m map[string]*Count, n int, less func(a, b *Count) bool) []Count {
+	all := make([]*Count, 0, len(m))
+	for _, c := range m {
+		c.SkipRate = rate(c.Skips, c.Plays)
+		c.ListenedMS = c.Listened.Milliseconds()
+		all = append(all, c)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if less(all[i], all[j]) || less(all[j], all[i]) {
+			return less(all[i], all[j])
+		}
+		return all[i].Name < all[j].Name
+	})
+	n = min(max(n, 0), len(all))
+	out := make([]Count, 0, n)
+	for _, c := range all[:n] {
+		out = append(out, *c)
+	}
+	return out
+}
+
+func buckets(m map[string]*Bucket) []Bucket {
+	out := make([]Bucket, 0, len(m))
+	for _, b := range m {
+		b.ListenedMS = b.Listened.Milliseconds()
+		out = append(out, *b)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.After(out[j].Start) })
+	return out
+}
+
+func rate(skips, plays int) float64 {
+	if skips+plays == 0 {
+		return 0
+	}
+	return float64(skips) / float64(skips+plays)
+}
+
+func nz(s, def string) string {
+	if strings.TrimSpace(s) == "" {
+		return def
+	}
+	return s
+}
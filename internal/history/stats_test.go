@@ -0,0 +1,177 @@
+package history
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+var utc = time.UTC
+
+// A finished (or, with skip, skipped) play of uri ending at "2006-01-02 15:04".
+func play(at, uri, artist, album string, played time.Duration, skip bool) Entry {
+	end, err := time.ParseInLocation("2006-01-02 15:04", at, utc)
+	if err != nil {
+		panic(err)
+	}
+	return Entry{
+		Ended: end, Started: end.Add(-played), URI: uri,
+		Title: strings.TrimSuffix(uri, ".flac"), Artist: artist, Album: album,
+		DurationMS: (4 * time.Minute).Milliseconds(), PlayedMS: played.Milliseconds(), Skipped: skip,
+	}
+}
+
+var entries = []Entry{
+	play("2026-10-11 09:00", "a1.flac", "Miles Davis", "Kind of Blue", 4*time.Minute, false),
+	play("2026-10-11 23:30", "a2.flac", "Miles Davis", "Kind of Blue", 4*time.Minute, false),
+	play("2026-10-12 00:10", "b1.flac", "Bill Evans", "Sunday at the Village Vanguard", 30*time.Second, true),
+	play("2026-10-17 10:00", "a1.flac", "miles davis", "Kind of Blue", 4*time.Minute, false),
+	play("2026-10-18 08:00", "c1.flac", "", "", 2*time.Minute, false),
+	play("2026-10-18 21:00", "b1.flac", "Bill Evans", "Sunday at the Village Vanguard", 10*time.Second, true),
+}
+
+// "name:plays/skips" per count, for comparing top lists.
+func counts(cs []Count) string {
+	out := make([]string, len(cs))
+	for i, c := range cs {
+		out[i] = fmt.Sprintf("%s:%d/%d", c.Name, c.Plays, c.Skips)
+	}
+	return strings.Join(out, " ")
+}
+
+func bucketsText(bs []Bucket) string {
+	out := make([]string, len(bs))
+	for i, b := range bs {
+		out[i] = fmt.Sprintf("%s:%d/%s", b.Label, b.Plays, b.Listened)
+	}
+	return strings.Join(out, " ")
+}
+
+func TestSummarizeTop(t *testing.T) {
+	for _, tc := range []struct {
+		name                    string
+		n                       int
+		artists, albums, tracks string
+		mostSkips               string
+	}{
+		{
+			name:      "all",
+			n:         10,
+			artists:   "Miles Davis:3/0 <unknown>:1/0 Bill Evans:0/2",
+			albums:    "Kind of Blue:3/0 Sunday at the Village Vanguard:0/2",
+			tracks:    "a1:2/0 a2:1/0 c1:1/0 b1:0/2",
+			mostSkips: "Bill Evans:0/2",
+		},
+		{
+			name:      "cut to n",
+			n:         1,
+			artists:   "Miles Davis:3/0",
+			albums:    "Kind of Blue:3/0",
+			tracks:    "a1:2/0",
+			mostSkips: "Bill Evans:0/2",
+		},
+		{
+			name: "zero",
+			n:    0,
+		},
+		{
+			name: "negative is zero",
+			n:    -1,
+		},
+	} {
+		r := Summarize(entries, time.Time{}, tc.n, utc)
+		for _, f := range []struct {
+			what      string
+			got, want string
+		}{
+			{"artists", counts(r.TopArtists), tc.artists},
+			{"albums", counts(r.TopAlbums), tc.albums},
+			{"tracks", counts(r.TopTracks), tc.tracks},
+			{"most skipped", counts(r.MostSkips), tc.mostSkips},
+		} {
+			if f.got != f.want {
+				t.Errorf("%s: top %s = %q, want %q", tc.name, f.what, f.got, f.want)
+			}
+		}
+	}
+}
+
+func TestSummarizeTotals(t *testing.T) {
+	r := Summarize(entries, time.Time{}, 10, utc)
+	if r.Plays != 4 || r.Skips != 2 {
+		t.Errorf("plays/skips = %d/%d, want 4/2", r.Plays, r.Skips)
+	}
+	if want := 1.0 / 3; r.SkipRate != want {
+		t.Errorf("skip rate = %v, want %v", r.SkipRate, want)
+	}
+	if want := 14*time.Minute + 40*time.Second; r.Listened != want || r.ListenedMS != want.Milliseconds() {
+		t.Errorf("listened = %v (%dms), want %v", r.Listened, r.ListenedMS, want)
+	}
+	if r.From != entries[0].Ended || r.To != entries[len(entries)-1].Ended {
+		t.Errorf("span = %v – %v, want %v – %v", r.From, r.To, entries[0].Ended, entries[len(entries)-1].Ended)
+	}
+	if got := r.TopArtists[0]; got.Artist != "" || got.Listened != 12*time.Minute {
+		t.Errorf("top artist = %+v, want no artist field and 12m listened", got)
+	}
+	if got := r.TopAlbums[0].Artist; got != "Miles Davis" {
+		t.Errorf("top album artist = %q, want the first spelling seen", got)
+	}
+}
+
+func TestSummarizeWindows(t *testing.T) {
+	berlin := time.FixedZone("CEST", 2*60*60)
+	for _, tc := range []struct {
+		name         string
+		since        string // "" = all
+		loc          *time.Location
+		plays, skips int
+		days, weeks  string
+	}{
+		{
+			name: "all in UTC", loc: utc, plays: 4, skips: 2,
+			days:  "2026-10-18:1/2m10s 2026-10-17:1/4m0s 2026-10-12:0/30s 2026-10-11:2/8m0s",
+			weeks: "2026-W42:2/6m40s 2026-W41:2/8m0s",
+		},
+		{
+			// 23:30 UTC on Sunday the 11th is Monday the 12th in CEST,
+			// which moves it into the next week as well
+			name: "days follow the location", loc: berlin, plays: 4, skips: 2,
+			days:  "2026-10-18:1/2m10s 2026-10-17:1/4m0s 2026-10-12:1/4m30s 2026-10-11:1/4m0s",
+			weeks: "2026-W42:3/10m40s 2026-W41:1/4m0s",
+		},
+		{
+			name: "since drops older plays", since: "2026-10-12 00:10", loc: utc, plays: 2, skips: 2,
+			days:  "2026-10-18:1/2m10s 2026-10-17:1/4m0s 2026-10-12:0/30s",
+			weeks: "2026-W42:2/6m40s",
+		},
+		{
+			name: "since after everything", since: "2026-10-19 00:00", loc: utc,
+		},
+	} {
+		var since time.Time
+		if tc.since != "" {
+			since = play(tc.since, "", "", "", 0, false).Ended
+		}
+		r := Summarize(entries, since, 10, tc.loc)
+		if r.Plays != tc.plays || r.Skips != tc.skips {
+			t.Errorf("%s: plays/skips = %d/%d, want %d/%d", tc.name, r.Plays, r.Skips, tc.plays, tc.skips)
+		}
+		if got := bucketsText(r.Days); got != tc.days {
+			t.Errorf("%s: days = %q, want %q", tc.name, got, tc.days)
+		}
+		if got := bucketsText(r.Weeks); got != tc.weeks {
+			t.Errorf("%s: weeks = %q, want %q", tc.name, got, tc.weeks)
+		}
+	}
+}
+
+func TestSummarizeWeekStartsMonday(t *testing.T) {
+	r := Summarize(entries[3:4], time.Time{}, 10, utc) // Saturday 2026-10-17
+	if len(r.Weeks) != 1 {
+		t.Fatalf("weeks = %d, want 1", len(r.Weeks))
+	}
+	if got := r.Weeks[0].Start.Format("2006-01-02 Mon"); got != "2026-10-12 Mon" {
+		t.Errorf("week start = %s, want 2026-10-12 Mon", got)
+	}
+}
@@ -0,0 +1,88 @@
+package lyrics
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Where to look for lyrics files on disk. Either dir may be empty.
+type Finder struct {
+	MusicDir string // MPD's music_directory, for sidecars next to the song
+	Dir      string // a flat lyrics collection
+}
+
+var exts = []string{".lrc", ".txt"}
+
+// Looks for a sidecar next to the song: <music_dir>/<uri minus ext>.lrc|.txt
+func (f Finder) Sidecar(uri string) (Lyrics, bool) {
+	if f.MusicDir == "" || uri == "" || strings.Contains(uri, "://") {
+		return Lyrics{}, false
+	}
+	base := strings.TrimSuffix(uri, path.Ext(uri))
+	return readFirst(filepath.Join(f.MusicDir, filepath.FromSlash(base)), "sidecar")
+}
+
+// Looks in the lyrics dir for "Artist - Title", then the song's file name.
+func (f Finder) Collection(uri, artist, title string) (Lyrics, bool) {
+	if f.Dir == "" {
+		return Lyrics{}, false
+	}
+	var names []string
+	if artist != "" && title != "" {
+		names = append(names, safeName(artist+" - "+title))
+	}
+	if uri != "" && !strings.Contains(uri, "://") {
+		b := path.Base(uri)
+		names = append(names, strings.TrimSuffix(b, path.Ext(b)))
+	}
+	for _, n := range names {
+		if l, ok := readFirst(filepath.Join(f.Dir, n), "lyrics dir"); ok {
+			return l, true
+		}
+	}
+	return Lyrics{}, false
+}
+
+func readFirst(stem, source string) (Lyrics, bool) {
+	for _, ext := range exts {
+		b, err := os.ReadFile(stem + ext)
+		if err != nil {
+			continue
+		}
+		l := Parse(string(b))
+		if l.Empty() {
+			continue
+		}
+		l.Source = source + ": " + filepath.Base(stem+ext)
+		return l, true
+	}
+	return Lyrics{}, false
+}
+
+// Tag names that carry lyrics in `readcomments` output.
+var commentKeys = []string{"lyrics", "unsyncedlyrics", "unsynced lyrics", "syncedlyrics"}
+
+// Picks lyrics out of MPD readcomments (keys compared case-insensitively).
+func FromComments(comments map[string]string) (Lyrics, bool) {
+	for _, want := range commentKeys {
+		for k, v := range comments {
+			if !strings.EqualFold(k, want) || strings.TrimSpace(v) == "" {
+				continue
+			}
+			l := Parse(v)
+			if l.Empty() {
+				continue
+			}
+			l.Source = "embedded"
+			return l, true
+		}
+	}
+	return Lyrics{}, false
+}
+
+// Path separators can't appear in file names.
+func safeName(s string) string {
+	return strings.NewReplacer("/", "_", "\\", "_").Replace(s)
+}
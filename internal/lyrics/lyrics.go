@@ -0,0 +1,116 @@
+// Package lyrics finds lyrics for a song, in sidecar files, a lyrics
+// directory or the file's own tags, and parses LRC so the line being sung
+// can follow playback.
+package lyrics
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Line struct {
+	At   time.Duration // zero for unsynced lyrics
+	Text string
+}
+
+type Lyrics struct {
+	Lines  []Line
+	Synced bool   // lines carry LRC timestamps
+	Source string // where they were found, for display
+}
+
+var (
+	stampRe = regexp.MustCompile(`^\[(\d+):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+	tagRe   = regexp.MustCompile(`^\[([a-zA-Z#]+):(.*)\]$`)
+	wordRe  = regexp.MustCompile(`<\d+:\d{1,2}(?:[.:]\d{1,3})?>`) // enhanced LRC word stamps
+)
+
+// Parses LRC ("[mm:ss.xx]text", several stamps per line allowed, honours
+// [offset:ms]). Text without any timestamps comes back unsynced as-is.
+func Parse(text string) Lyrics {
+	text = strings.ReplaceAll(strings.TrimPrefix(text, "\ufeff"), "\r\n", "\n")
+	var (
+		synced []Line
+		plain  []string
+		offset time.Duration
+	)
+	for _, raw := range strings.Split(text, "\n") {
+		ln := strings.TrimSpace(raw)
+		var stamps []time.Duration
+		for {
+			m := stampRe.FindStringSubmatch(ln)
+			if m == nil {
+				break
+			}
+			stamps = append(stamps, stamp(m[1], m[2], m[3]))
+			ln = ln[len(m[0]):]
+		}
+		if len(stamps) == 0 {
+			if m := tagRe.FindStringSubmatch(ln); m != nil {
+				if strings.EqualFold(m[1], "offset") {
+					if ms, err := strconv.Atoi(strings.TrimSpace(m[2])); err == nil {
+						offset = time.Duration(ms) * time.Millisecond
+					}
+				}
+				continue // [ar:..], [ti:..] and friends
+			}
+			plain = append(plain, strings.TrimRight(raw, " \t"))
+			continue
+		}
+		ln = strings.TrimSpace(wordRe.ReplaceAllString(ln, ""))
+		for _, at := range stamps {
+			synced = append(synced, Line{At: at, Text: ln})
+		}
+	}
+
+	if len(synced) == 0 {
+		// drop leading/trailing blank lines
+		for len(plain) > 0 && plain[0] == "" {
+			plain = plain[1:]
+		}
+		for len(plain) > 0 && plain[len(plain)-1] == "" {
+			plain = plain[:len(plain)-1]
+		}
+		out := Lyrics{Lines: make([]Line, len(plain))}
+		for i, p := range plain {
+			out.Lines[i].Text = p
+		}
+		return out
+	}
+
+	// positive offset shows lines earlier
+	for i := range synced {
+		synced[i].At = max(0, synced[i].At-offset)
+	}
+	sort.SliceStable(synced, func(i, j int) bool { return synced[i].At < synced[j].At })
+	return Lyrics{Lines: synced, Synced: true}
+}
+
+func stamp(mins, secs, frac string) time.Duration {
+	m, _ := strconv.Atoi(mins)
+	s, _ := strconv.Atoi(secs)
+	d := time.Duration(m)*time.Minute + time.Duration(s)*time.Second
+	if frac != "" {
+		f, _ := strconv.Atoi(frac)
+		// ".5" = 500ms, ".05" = 50ms, ".005" = 5ms
+		for i := len(frac); i < 3; i++ {
+			f *= 10
+		}
+		d += time.Duration(f) * time.Millisecond
+	}
+	return d
+}
+
+// Index of the line being sung at elapsed, -1 before the first line
+// (or always, for unsynced lyrics).
+func (l Lyrics) Current(elapsed time.Duration) int {
+	if !l.Synced {
+		return -1
+	}
+	return sort.Search(len(l.Lines), func(i int) bool { return l.Lines[i].At > elapsed }) - 1
+}
+
+func (l Lyrics) Empty() bool { return len(l.Lines) == 0 }
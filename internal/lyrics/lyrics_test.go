@@ -0,0 +1,116 @@
+package lyrics
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// "at text" per line, for comparing parses.
+func linesText(l Lyrics) string {
+	out := make([]string, len(l.Lines))
+	for i, ln := range l.Lines {
+		out[i] = fmt.Sprintf("%s %s", ln.At, ln.Text)
+	}
+	return strings.Join(out, "\n")
+}
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		in     string
+		synced bool
+		want   string
+	}{
+		{
+			name:   "plain",
+			in:     "\n\nFirst line\n  indented\n\nLast line  \n\n",
+			synced: false,
+			want:   "0s First line\n0s   indented\n0s \n0s Last line",
+		},
+		{
+			name:   "fractions",
+			in:     "[00:01.5]a\n[00:02.05]b\n[00:03.005]c\n[00:04]d\n[00:05:25]e",
+			synced: true,
+			want:   "1.5s a\n2.05s b\n3.005s c\n4s d\n5.25s e",
+		},
+		{
+			name:   "minutes past an hour",
+			in:     "[61:02.00]late",
+			synced: true,
+			want:   "1h1m2s late",
+		},
+		{
+			name:   "several stamps per line, sorted",
+			in:     "[00:30.00][00:10.00]chorus\n[00:20.00]verse",
+			synced: true,
+			want:   "10s chorus\n20s verse\n30s chorus",
+		},
+		{
+			name:   "tags skipped, positive offset shows lines earlier",
+			in:     "[ar:Someone]\n[ti:Something]\n[offset:+500]\n[00:10.00]a\n[00:00.20]b",
+			synced: true,
+			want:   "0s b\n9.5s a",
+		},
+		{
+			name:   "negative offset",
+			in:     "[offset: -250]\n[00:01.00]a",
+			synced: true,
+			want:   "1.25s a",
+		},
+		{
+			name:   "enhanced word stamps dropped",
+			in:     "[00:12.00]<00:12.00>Hello <00:12.50>there <00:13.1>world",
+			synced: true,
+			want:   "12s Hello there world",
+		},
+		{
+			name:   "BOM, CRLF and blank synced lines",
+			in:     "\ufeff[00:01.00]a\r\n[00:02.00]\r\n[00:03.00]b\r\n",
+			synced: true,
+			want:   "1s a\n2s \n3s b",
+		},
+		{
+			name:   "stray text among synced lines is dropped",
+			in:     "Title\n[00:01.00]a",
+			synced: true,
+			want:   "1s a",
+		},
+	} {
+		got := Parse(tc.in)
+		if got.Synced != tc.synced {
+			t.Errorf("%s: synced = %v, want %v", tc.name, got.Synced, tc.synced)
+		}
+		if s := linesText(got); s != tc.want {
+			t.Errorf("%s: lines =\n%s\nwant\n%s", tc.name, s, tc.want)
+		}
+	}
+}
+
+func TestCurrent(t *testing.T) {
+	l := Parse("[00:05.00]a\n[00:10.00]b\n[00:10.00]c\n[00:20.00]d")
+	for _, tc := range []struct {
+		at   time.Duration
+		want int
+	}{
+		{0, -1},
+		{5*time.Second - time.Millisecond, -1},
+		{5 * time.Second, 0},
+		{9 * time.Second, 0},
+		{10 * time.Second, 2}, // the last of lines sharing a stamp
+		{19 * time.Second, 2},
+		{20 * time.Second, 3},
+		{time.Hour, 3},
+	} {
+		if got := l.Current(tc.at); got != tc.want {
+			t.Errorf("Current(%s) = %d, want %d", tc.at, got, tc.want)
+		}
+	}
+	if got := Parse("just words").Current(time.Minute); got != -1 {
+		t.Errorf("unsynced Current = %d, want -1", got)
+	}
+	if got := (Lyrics{Synced: true}).Current(time.Minute); got != -1 {
+		t.Errorf("empty Current = %d, want -1", got)
+	}
+}
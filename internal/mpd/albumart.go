@@ -0,0 +1,95 @@
+package mpd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// AlbumArt's error when the song has no cover.
+var ErrNoArt = errors.New("no album art")
+
+// Largest image we'll read
+const maxArt = 16 << 20
+
+func (t *tcpConn) AlbumArt(ctx context.Context, uri string) ([]byte, error) {
+	data, err := t.readBinary(ctx, "albumart", uri)
+	if err == nil || !strings.HasPrefix(err.Error(), "ACK [50@") {
+		return data, err
+	}
+	data, err = t.readBinary(ctx, "readpicture", uri)
+	if err != nil && strings.HasPrefix(err.Error(), "ACK [5@") { // MPD < 0.22
+		return nil, ErrNoArt
+	}
+	return data, err
+}
+
+// Fetches a whole binary response chunk by chunk ("albumart" and
+// "readpicture" send one chunk per call, at an offset).
+func (t *tcpConn) readBinary(ctx context.Context, cmd, uri string) ([]byte, error) {
+	var data []byte
+	for {
+		size, chunk, err := t.binaryChunk(ctx, fmt.Sprintf(`%s "%s" %d`, cmd, escape(uri), len(data)))
+		if err != nil {
+			return nil, err
+		}
+		if size > maxArt {
+			return nil, fmt.Errorf("%s: image too large (%d bytes)", cmd, size)
+		}
+		if len(chunk) == 0 {
+			if len(data) == 0 {
+				return nil, ErrNoArt
+			}
+			return data, nil
+		}
+		data = append(data, chunk...)
+		if len(data) >= size {
+			return data, nil
+		}
+	}
+}
+
+// One response of the binary protocol: "size: N", "binary: M", M raw bytes
+// and a newline, then OK.
+func (t *tcpConn) binaryChunk(ctx context.Context, line string) (int, []byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	deadline := time.Now().Add(t.timeout)
+	_ = t.conn.SetWriteDeadline(deadline)
+	if _, err := t.conn.Write([]byte(line + "\n")); err != nil {
+		return 0, nil, err
+	}
+
+	_ = t.conn.SetReadDeadline(deadline)
+	size := 0
+	var chunk []byte
+	for {
+		s, err := t.rd.ReadString('\n')
+		if err != nil {
+			return 0, nil, err
+		}
+		s = strings.TrimRight(s, "\r\n")
+		switch {
+		case s == "OK":
+			return size, chunk, nil
+		case strings.HasPrefix(s, "ACK "):
+			return 0, nil, errors.New(s)
+		case strings.HasPrefix(s, "size: "):
+			size = parseIntSafe(strings.TrimPrefix(s, "size: "))
+		case strings.HasPrefix(s, "binary: "):
+			n := parseIntSafe(strings.TrimPrefix(s, "binary: "))
+			if n > maxArt {
+				return 0, nil, fmt.Errorf("binary chunk too large (%d bytes)", n)
+			}
+			chunk = make([]byte, n+1) // and the newline after it
+			if _, err := io.ReadFull(t.rd, chunk); err != nil {
+				return 0, nil, err
+			}
+			chunk = chunk[:n]
+		}
+	}
+}
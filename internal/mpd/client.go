@@ -3,6 +3,7 @@ package mpd
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"strconv"
@@ -12,9 +13,10 @@ import (
 )
 
 type Config struct {
-	Host    string
-	Port    int
-	Timeout time.Duration
+	Host      string
+	Port      int
+	Timeout   time.Duration
+	Partition string // switched to after connecting ("" = default)
 }
 
 type Track struct {
@@ -22,18 +24,31 @@ type Track struct {
 	Title    string
 	Artist   string
 	Album    string
+	Genre    string
+	Composer string
+	Work     string
+	Year     int
 	Duration time.Duration
 	TrackNo  int
 	DiscNo   int
+
+	// Queue entries only
+	Pos int
+	ID  int
 }
 
 type NowPlaying struct {
+	URI      string
 	Title    string
 	Artist   string
 	Album    string
 	Elapsed  time.Duration
 	Duration time.Duration
 	Playing  bool
+	State    string // play, pause or stop
+	SongID   int    // queue id, tells repeats of the same file apart
+
+	UpdatingDB int // database update job id, 0 when idle
 }
 
 // Produces a connection for reconnecting
@@ -46,15 +61,27 @@ type Conn interface {
 
 	// Library
 	ListAll(ctx context.Context) ([]Track, error)
+	LsInfo(ctx context.Context, path string) (dirs []string, tracks []Track, err error)
 
 	// Playback controls
 	Play(ctx context.Context, uri string) error
 	TogglePause(ctx context.Context) error
 	Next(ctx context.Context) error
 	Prev(ctx context.Context) error
+	Stop(ctx context.Context) error
+	Resume(ctx context.Context) error
+	SeekCur(ctx context.Context, pos time.Duration) error
+
+	Pause(ctx context.Context, on bool) error
+	SetVolume(ctx context.Context, vol int) error
+	SetRepeat(ctx context.Context, on bool) error
+	SetRandom(ctx context.Context, on bool) error
+	SetSingle(ctx context.Context, mode string) error
 
 	// Status
 	Status(ctx context.Context) (NowPlaying, error)
+	PlayerStatus(ctx context.Context) (PlayerStatus, error)
+	CurrentSong(ctx context.Context) (Track, error)
 
 	Idle(ctx context.Context, subs []string) ([]string, error)
 
@@ -63,6 +90,63 @@ type Conn interface {
 	QueueAddID(ctx context.Context, uri string) (int, error)
 	PlayPos(ctx context.Context, pos int) error
 	PlayID(ctx context.Context, id int) error
+	QueueDeleteID(ctx context.Context, id int) error
+	QueueMoveID(ctx context.Context, id, pos int) error
+	QueueList(ctx context.Context) ([]Track, error)
+
+	// Database
+	Search(ctx context.Context, tag, value string) ([]Track, error)
+	SearchFilter(ctx context.Context, expr string) ([]Track, error)
+	Update(ctx context.Context, path string) (int, error)
+	Rescan(ctx context.Context, path string) (int, error)
+
+	// Audio outputs
+	Outputs(ctx context.Context) ([]Output, error)
+	EnableOutput(ctx context.Context, id int) error
+	DisableOutput(ctx context.Context, id int) error
+	ToggleOutput(ctx context.Context, id int) error
+	OutputSet(ctx context.Context, id int, name, value string) error
+
+	// Partitions: independent queues and players in one daemon
+	Partition(ctx context.Context, name string) error
+	ListPartitions(ctx context.Context) ([]string, error)
+	NewPartition(ctx context.Context, name string) error
+	DelPartition(ctx context.Context, name string) error
+	MoveOutput(ctx context.Context, name string) error
+
+	// Client-to-client channels
+	Subscribe(ctx context.Context, channel string) error
+	Unsubscribe(ctx context.Context, channel string) error
+	Channels(ctx context.Context) ([]string, error)
+	ReadMessages(ctx context.Context) ([]Message, error)
+	SendMessage(ctx context.Context, channel, text string) error
+
+	// Storage mounts in the music directory
+	Mount(ctx context.Context, point, uri string) error
+	Unmount(ctx context.Context, point string) error
+	ListMounts(ctx context.Context) ([]Mount, error)
+	ListNeighbors(ctx context.Context) ([]Neighbor, error)
+
+	// Tags MPD doesn't index (e.g. embedded lyrics)
+	ReadComments(ctx context.Context, uri string) (map[string]string, error)
+
+	// Cover art: a cover file next to the song, else an embedded picture
+	AlbumArt(ctx context.Context, uri string) ([]byte, error)
+
+	// Stored playlists
+	Playlists(ctx context.Context) ([]string, error)
+	PlaylistSongs(ctx context.Context, name string) ([]Track, error)
+	PlaylistAdd(ctx context.Context, name, uri string) error
+	PlaylistLoad(ctx context.Context, name string) error
+	PlaylistSave(ctx context.Context, name string) error
+	PlaylistRemove(ctx context.Context, name string) error
+
+	// Stickers (song stickers only)
+	StickerGet(ctx context.Context, uri, name string) (string, error)
+	StickerSet(ctx context.Context, uri, name, value string) error
+	StickerDelete(ctx context.Context, uri, name string) error
+	StickerList(ctx context.Context, uri string) (map[string]string, error)
+	StickerFind(ctx context.Context, dir, name string) (map[string]string, error)
 }
 
 var _ Client = (*client)(nil)
@@ -101,11 +185,18 @@ func (c *client) Connect(ctx context.Context, cfg Config) (Conn, error) {
 		return nil, fmt.Errorf("unexpected greeting: %q", hello)
 	}
 
-	return &tcpConn{
+	t := &tcpConn{
 		conn:    nc,
 		rd:      br,
 		timeout: timeout,
-	}, nil
+	}
+	if cfg.Partition != "" {
+		if err := t.Partition(ctx, cfg.Partition); err != nil {
+			_ = nc.Close()
+			return nil, fmt.Errorf("partition %q: %w", cfg.Partition, err)
+		}
+	}
+	return t, nil
 }
 
 type tcpConn struct {
@@ -141,7 +232,7 @@ func (t *tcpConn) cmd(ctx context.Context, line string) ([]string, error) {
 			return out, nil
 		}
 		if strings.HasPrefix(s, "ACK ") {
-			return nil, fmt.Errorf(s)
+			return nil, errors.New(s)
 		}
 		out = append(out, s)
 	}
@@ -152,6 +243,27 @@ func (t *tcpConn) ListAll(ctx context.Context) ([]Track, error) {
 	if err != nil {
 		return nil, err
 	}
+	return parseTracks(lines), nil
+}
+
+// Lists one directory of the music database: subdirectories and songs.
+func (t *tcpConn) LsInfo(ctx context.Context, path string) ([]string, []Track, error) {
+	lines, err := t.cmd(ctx, `lsinfo "`+escape(path)+`"`)
+	if err != nil {
+		return nil, nil, err
+	}
+	var dirs []string
+	for _, ln := range lines {
+		if strings.HasPrefix(ln, "directory: ") {
+			dirs = append(dirs, strings.TrimPrefix(ln, "directory: "))
+		}
+	}
+	return dirs, parseTracks(lines), nil
+}
+
+// Parses "file:"-delimited song blocks as returned by listallinfo, lsinfo,
+// playlistinfo, find, etc.
+func parseTracks(lines []string) []Track {
 	var tracks []Track
 	var cur *Track
 
@@ -167,26 +279,53 @@ func (t *tcpConn) ListAll(ctx context.Context) ([]Track, error) {
 		case strings.HasPrefix(ln, "file: "):
 			flush()
 			cur = &Track{URI: strings.TrimPrefix(ln, "file: ")}
+		case strings.HasPrefix(ln, "directory: "), strings.HasPrefix(ln, "playlist: "):
+			// ignore directory/playlist entries (and their Last-Modified lines)
+			flush()
 		case cur != nil && strings.HasPrefix(ln, "Title: "):
 			cur.Title = strings.TrimPrefix(ln, "Title: ")
 		case cur != nil && strings.HasPrefix(ln, "Artist: "):
 			cur.Artist = strings.TrimPrefix(ln, "Artist: ")
 		case cur != nil && strings.HasPrefix(ln, "Album: "):
 			cur.Album = strings.TrimPrefix(ln, "Album: ")
+		case cur != nil && strings.HasPrefix(ln, "Genre: "):
+			cur.Genre = strings.TrimPrefix(ln, "Genre: ")
+		case cur != nil && strings.HasPrefix(ln, "Composer: "):
+			cur.Composer = strings.TrimPrefix(ln, "Composer: ")
+		case cur != nil && strings.HasPrefix(ln, "Work: "):
+			cur.Work = strings.TrimPrefix(ln, "Work: ")
+		case cur != nil && strings.HasPrefix(ln, "Date: "):
+			cur.Year = parseYear(strings.TrimPrefix(ln, "Date: "))
 		case cur != nil && strings.HasPrefix(ln, "Time: "):
 			secs := strings.TrimPrefix(ln, "Time: ")
 			if d, ok := parseSecs(secs); ok {
 				cur.Duration = d
 			}
-			// ignore directory/playlist/etc lines
 		case cur != nil && strings.HasPrefix(ln, "Track: "):
 			cur.TrackNo = parseTrackNum(strings.TrimPrefix(ln, "Track: "))
 		case cur != nil && strings.HasPrefix(ln, "Disc: "):
 			cur.DiscNo = parseIntSafe(strings.TrimPrefix(ln, "Disc: "))
+		case cur != nil && strings.HasPrefix(ln, "Pos: "):
+			cur.Pos = parseIntSafe(strings.TrimPrefix(ln, "Pos: "))
+		case cur != nil && strings.HasPrefix(ln, "Id: "):
+			cur.ID = parseIntSafe(strings.TrimPrefix(ln, "Id: "))
 		}
 	}
 	flush()
-	return tracks, nil
+	return tracks
+}
+
+// Dates come in many shapes ("1999", "1999-04-01", "1999/04"); keep the year.
+func parseYear(s string) int {
+	s = strings.TrimSpace(s)
+	if len(s) < 4 {
+		return 0
+	}
+	n, err := strconv.Atoi(s[:4])
+	if err != nil {
+		return 0
+	}
+	return n
 }
 
 func parseIntSafe(s string) int {
@@ -258,6 +397,63 @@ func (t *tcpConn) Prev(ctx context.Context) error {
 	return err
 }
 
+func (t *tcpConn) Stop(ctx context.Context) error {
+	_, err := t.cmd(ctx, "stop")
+	return err
+}
+
+// Starts playback at the current song (or the first one when stopped).
+func (t *tcpConn) Resume(ctx context.Context) error {
+	_, err := t.cmd(ctx, "play")
+	return err
+}
+
+func (t *tcpConn) Pause(ctx context.Context, on bool) error {
+	_, err := t.cmd(ctx, "pause "+boolArg(on))
+	return err
+}
+
+func (t *tcpConn) SetVolume(ctx context.Context, vol int) error {
+	_, err := t.cmd(ctx, fmt.Sprintf("setvol %d", clampVol(vol)))
+	return err
+}
+
+func clampVol(v int) int {
+	return min(100, max(0, v))
+}
+
+func (t *tcpConn) SetRepeat(ctx context.Context, on bool) error {
+	_, err := t.cmd(ctx, "repeat "+boolArg(on))
+	return err
+}
+
+func (t *tcpConn) SetRandom(ctx context.Context, on bool) error {
+	_, err := t.cmd(ctx, "random "+boolArg(on))
+	return err
+}
+
+// mode is "0", "1" or "oneshot", as PlayerStatus reports it.
+func (t *tcpConn) SetSingle(ctx context.Context, mode string) error {
+	_, err := t.cmd(ctx, "single "+mode)
+	return err
+}
+
+func boolArg(on bool) string {
+	if on {
+		return "1"
+	}
+	return "0"
+}
+
+// Seeks within the current song to an absolute position.
+func (t *tcpConn) SeekCur(ctx context.Context, pos time.Duration) error {
+	if pos < 0 {
+		pos = 0
+	}
+	_, err := t.cmd(ctx, fmt.Sprintf("seekcur %.3f", pos.Seconds()))
+	return err
+}
+
 func (t *tcpConn) Status(ctx context.Context) (NowPlaying, error) {
 	stLines, err := t.cmd(ctx, "status")
 	if err != nil {
@@ -266,7 +462,10 @@ func (t *tcpConn) Status(ctx context.Context) (NowPlaying, error) {
 	m := kvLower(stLines)
 
 	var np NowPlaying
-	np.Playing = m["state"] == "play"
+	np.State = m["state"]
+	np.Playing = np.State == "play"
+	np.UpdatingDB = parseIntSafe(m["updating_db"])
+	np.SongID = parseIntSafe(m["songid"])
 
 	// Prefer precise fields if present
 	if v, ok := m["elapsed"]; ok {
@@ -287,6 +486,7 @@ func (t *tcpConn) Status(ctx context.Context) (NowPlaying, error) {
 	// Merge metadata from currentsong (best-effort)
 	if csLines, err := t.cmd(ctx, "currentsong"); err == nil {
 		cs := kvLower(csLines)
+		np.URI = cs["file"]
 		np.Title = cs["title"]
 		np.Artist = cs["artist"]
 		np.Album = cs["album"]
@@ -295,27 +495,59 @@ func (t *tcpConn) Status(ctx context.Context) (NowPlaying, error) {
 	return np, nil
 }
 
+// Blocks until one of subs changes (any subsystem when empty) and returns
+// the changed names. Cancelling ctx sends "noidle"; whatever changed by
+// then is returned along with ctx.Err(). Idle holds the connection, so use
+// a dedicated Conn for it.
 func (t *tcpConn) Idle(ctx context.Context, subs []string) ([]string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	cmd := "idle"
 	if len(subs) > 0 {
 		cmd += " " + strings.Join(subs, " ")
 	}
-	lines, err := t.cmd(ctx, cmd)
-	if err != nil {
-		// Treat timeouts as "no events yet" so callers can re-issue Idle.
-		if ne, ok := err.(net.Error); ok && ne.Timeout() {
-			return nil, nil
-		}
+	_ = t.conn.SetWriteDeadline(time.Now().Add(t.timeout))
+	if _, err := t.conn.Write([]byte(cmd + "\n")); err != nil {
 		return nil, err
 	}
+	_ = t.conn.SetReadDeadline(time.Time{})
+
+	done := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		defer close(done)
+		_ = t.conn.SetWriteDeadline(time.Now().Add(t.timeout))
+		_, _ = t.conn.Write([]byte("noidle\n"))
+		// don't hang if the server went away meanwhile
+		_ = t.conn.SetReadDeadline(time.Now().Add(t.timeout))
+	})
+	defer func() {
+		// a "noidle" already on its way must be out before the next
+		// command gets the connection
+		if !stop() {
+			<-done
+		}
+	}()
 
 	var out []string
-	for _, ln := range lines {
-		if strings.HasPrefix(ln, "changed: ") {
-			out = append(out, strings.TrimPrefix(ln, "changed: "))
+	for {
+		s, err := t.rd.ReadString('\n')
+		if err != nil {
+			return out, err
+		}
+		s = strings.TrimRight(s, "\r\n")
+		switch {
+		case s == "OK":
+			if len(out) == 0 && ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return out, nil
+		case strings.HasPrefix(s, "ACK "):
+			return nil, errors.New(s)
+		case strings.HasPrefix(s, "changed: "):
+			out = append(out, strings.TrimPrefix(s, "changed: "))
 		}
 	}
-	return out, nil
 }
 
 func (t *tcpConn) QueueClear(ctx context.Context) error {
@@ -349,6 +581,146 @@ func (t *tcpConn) PlayID(ctx context.Context, id int) error {
 	_, err := t.cmd(ctx, fmt.Sprintf("playid %d", id))
 	return err
 }
+func (t *tcpConn) QueueDeleteID(ctx context.Context, id int) error {
+	_, err := t.cmd(ctx, fmt.Sprintf("deleteid %d", id))
+	return err
+}
+
+// Moves queue entry id to position pos.
+func (t *tcpConn) QueueMoveID(ctx context.Context, id, pos int) error {
+	_, err := t.cmd(ctx, fmt.Sprintf("moveid %d %d", id, pos))
+	return err
+}
+
+// Raw file comments; a key repeated over several lines is joined with "\n".
+func (t *tcpConn) ReadComments(ctx context.Context, uri string) (map[string]string, error) {
+	lines, err := t.cmd(ctx, `readcomments "`+escape(uri)+`"`)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]string{}
+	for _, ln := range lines {
+		k, v, ok := strings.Cut(ln, ": ")
+		if !ok {
+			continue
+		}
+		if prev, ok := out[k]; ok {
+			v = prev + "\n" + v
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+func (t *tcpConn) QueueList(ctx context.Context) ([]Track, error) {
+	lines, err := t.cmd(ctx, "playlistinfo")
+	if err != nil {
+		return nil, err
+	}
+	return parseTracks(lines), nil
+}
+
+// Case-insensitive substring search; tag "any" matches every tag.
+func (t *tcpConn) Search(ctx context.Context, tag, value string) ([]Track, error) {
+	lines, err := t.cmd(ctx, `search "`+escape(tag)+`" "`+escape(value)+`"`)
+	if err != nil {
+		return nil, err
+	}
+	return parseTracks(lines), nil
+}
+
+// Case-insensitive search with an MPD filter expression, e.g.
+// `((genre == "Jazz") AND (date == "1959"))`. See FilterEq.
+func (t *tcpConn) SearchFilter(ctx context.Context, expr string) ([]Track, error) {
+	lines, err := t.cmd(ctx, `search "`+escape(expr)+`"`)
+	if err != nil {
+		return nil, err
+	}
+	return parseTracks(lines), nil
+}
+
+// s as one quoted protocol argument, for code that speaks MPD itself.
+func Quote(s string) string {
+	return `"` + escape(s) + `"`
+}
+
+// `(tag == "value")` (or != when neg) with value quoted for a filter.
+func FilterEq(tag, value string, neg bool) string {
+	op := "=="
+	if neg {
+		op = "!="
+	}
+	return "(" + tag + " " + op + ` "` + escape(value) + `")`
+}
+
+// Starts a database update of path ("" = everything); returns the job id.
+func (t *tcpConn) Update(ctx context.Context, path string) (int, error) {
+	return t.updateDB(ctx, "update", path)
+}
+
+// Like Update, but also re-reads files that haven't changed.
+func (t *tcpConn) Rescan(ctx context.Context, path string) (int, error) {
+	return t.updateDB(ctx, "rescan", path)
+}
+
+func (t *tcpConn) updateDB(ctx context.Context, cmd, path string) (int, error) {
+	if path != "" {
+		cmd += ` "` + escape(path) + `"`
+	}
+	lines, err := t.cmd(ctx, cmd)
+	if err != nil {
+		return 0, err
+	}
+	return parseIntSafe(kvLower(lines)["updating_db"]), nil
+}
+
+func (t *tcpConn) PlaylistLoad(ctx context.Context, name string) error {
+	_, err := t.cmd(ctx, `load "`+escape(name)+`"`)
+	return err
+}
+
+func (t *tcpConn) PlaylistSave(ctx context.Context, name string) error {
+	_, err := t.cmd(ctx, `save "`+escape(name)+`"`)
+	return err
+}
+
+// Deletes a stored playlist; a missing one is not an error.
+func (t *tcpConn) PlaylistRemove(ctx context.Context, name string) error {
+	_, err := t.cmd(ctx, `rm "`+escape(name)+`"`)
+	if err != nil && strings.HasPrefix(err.Error(), "ACK [50@") {
+		return nil
+	}
+	return err
+}
+
+func (t *tcpConn) PlaylistAdd(ctx context.Context, name, uri string) error {
+	_, err := t.cmd(ctx, `playlistadd "`+escape(name)+`" "`+escape(uri)+`"`)
+	return err
+}
+
+// Names of the stored playlists.
+func (t *tcpConn) Playlists(ctx context.Context) ([]string, error) {
+	lines, err := t.cmd(ctx, "listplaylists")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, ln := range lines {
+		if name, ok := strings.CutPrefix(ln, "playlist: "); ok {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// Songs of a stored playlist, with tags.
+func (t *tcpConn) PlaylistSongs(ctx context.Context, name string) ([]Track, error) {
+	lines, err := t.cmd(ctx, `listplaylistinfo "`+escape(name)+`"`)
+	if err != nil {
+		return nil, err
+	}
+	return parseTracks(lines), nil
+}
 
 func escape(s string) string {
 	s = strings.ReplaceAll(s, `\`, `\\`)
@@ -0,0 +1,71 @@
+package mpd
+
+import (
+	"context"
+	"strings"
+)
+
+// Client-to-client messages. A connection subscribes to channels and
+// collects what others send there until it calls ReadMessages; the
+// "message" idle event says something is waiting.
+
+type Message struct {
+	Channel string
+	Text    string
+}
+
+func (t *tcpConn) Subscribe(ctx context.Context, channel string) error {
+	_, err := t.cmd(ctx, `subscribe "`+escape(channel)+`"`)
+	return err
+}
+
+func (t *tcpConn) Unsubscribe(ctx context.Context, channel string) error {
+	_, err := t.cmd(ctx, `unsubscribe "`+escape(channel)+`"`)
+	return err
+}
+
+// Channels with at least one subscriber.
+func (t *tcpConn) Channels(ctx context.Context) ([]string, error) {
+	lines, err := t.cmd(ctx, "channels")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, ln := range lines {
+		if name, ok := strings.CutPrefix(ln, "channel: "); ok {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// Messages received on this connection's channels since the last call,
+// oldest first.
+func (t *tcpConn) ReadMessages(ctx context.Context) ([]Message, error) {
+	lines, err := t.cmd(ctx, "readmessages")
+	if err != nil {
+		return nil, err
+	}
+	var out []Message
+	for _, ln := range lines {
+		k, v, ok := strings.Cut(ln, ": ")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "channel":
+			out = append(out, Message{Channel: v})
+		case "message":
+			if len(out) > 0 {
+				out[len(out)-1].Text = v
+			}
+		}
+	}
+	return out, nil
+}
+
+// Sends text to every subscriber of channel; an error when there are none.
+func (t *tcpConn) SendMessage(ctx context.Context, channel, text string) error {
+	_, err := t.cmd(ctx, `sendmessage "`+escape(channel)+`" "`+escape(text)+`"`)
+	return err
+}
@@ -0,0 +1,81 @@
+package mpd
+
+import (
+	"context"
+	"strings"
+)
+
+// A storage mounted into the music directory; Point "" is the root.
+type Mount struct {
+	Point   string
+	Storage string // URI, e.g. "nfs://nas/music"
+}
+
+// A storage MPD found on the network, ready to mount.
+type Neighbor struct {
+	URI  string
+	Name string
+}
+
+// Mounts the storage at uri on point, a path in the music directory.
+func (t *tcpConn) Mount(ctx context.Context, point, uri string) error {
+	_, err := t.cmd(ctx, `mount "`+escape(point)+`" "`+escape(uri)+`"`)
+	return err
+}
+
+func (t *tcpConn) Unmount(ctx context.Context, point string) error {
+	_, err := t.cmd(ctx, `unmount "`+escape(point)+`"`)
+	return err
+}
+
+func (t *tcpConn) ListMounts(ctx context.Context) ([]Mount, error) {
+	lines, err := t.cmd(ctx, "listmounts")
+	if err != nil {
+		return nil, err
+	}
+	return ParseMounts(lines), nil
+}
+
+// Mounts from a listmounts response.
+func ParseMounts(lines []string) []Mount {
+	var out []Mount
+	for _, ln := range lines {
+		k, v, ok := strings.Cut(ln, ": ")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "mount":
+			out = append(out, Mount{Point: v})
+		case "storage":
+			if len(out) > 0 {
+				out[len(out)-1].Storage = v
+			}
+		}
+	}
+	return out
+}
+
+// Storages the neighbor plugins (SMB, UPnP, ...) can see.
+func (t *tcpConn) ListNeighbors(ctx context.Context) ([]Neighbor, error) {
+	lines, err := t.cmd(ctx, "listneighbors")
+	if err != nil {
+		return nil, err
+	}
+	var out []Neighbor
+	for _, ln := range lines {
+		k, v, ok := strings.Cut(ln, ": ")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "neighbor":
+			out = append(out, Neighbor{URI: v})
+		case "name":
+			if len(out) > 0 {
+				out[len(out)-1].Name = v
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,79 @@
+package mpd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// An audio output as listed by `outputs`.
+type Output struct {
+	ID         int
+	Name       string
+	Plugin     string
+	Enabled    bool
+	Attributes map[string]string // runtime attributes, see OutputSet
+}
+
+func (t *tcpConn) Outputs(ctx context.Context) ([]Output, error) {
+	lines, err := t.cmd(ctx, "outputs")
+	if err != nil {
+		return nil, err
+	}
+	return parseOutputs(lines), nil
+}
+
+func parseOutputs(lines []string) []Output {
+	var outs []Output
+	for _, ln := range lines {
+		k, v, ok := strings.Cut(ln, ": ")
+		if !ok {
+			continue
+		}
+		if k == "outputid" {
+			outs = append(outs, Output{ID: parseIntSafe(v)})
+			continue
+		}
+		if len(outs) == 0 {
+			continue
+		}
+		o := &outs[len(outs)-1]
+		switch k {
+		case "outputname":
+			o.Name = v
+		case "plugin":
+			o.Plugin = v
+		case "outputenabled":
+			o.Enabled = v == "1"
+		case "attribute":
+			if name, val, ok := strings.Cut(v, "="); ok {
+				if o.Attributes == nil {
+					o.Attributes = map[string]string{}
+				}
+				o.Attributes[name] = val
+			}
+		}
+	}
+	return outs
+}
+
+func (t *tcpConn) EnableOutput(ctx context.Context, id int) error {
+	_, err := t.cmd(ctx, fmt.Sprintf("enableoutput %d", id))
+	return err
+}
+
+func (t *tcpConn) DisableOutput(ctx context.Context, id int) error {
+	_, err := t.cmd(ctx, fmt.Sprintf("disableoutput %d", id))
+	return err
+}
+
+func (t *tcpConn) ToggleOutput(ctx context.Context, id int) error {
+	_, err := t.cmd(ctx, fmt.Sprintf("toggleoutput %d", id))
+	return err
+}
+
+// Sets a runtime attribute of an output (e.g. "dop" or "allowed_formats").
+func (t *tcpConn) OutputSet(ctx context.Context, id int, name, value string) error {
+	_, err := t.cmd(ctx, fmt.Sprintf(`outputset %d "%s" "%s"`, id, escape(name), escape(value)))
+	return err
+}
@@ -0,0 +1,48 @@
+package mpd
+
+import (
+	"context"
+	"strings"
+)
+
+// Partitions (MPD 0.22+) are independent queues and players inside one
+// daemon; each connection works on one of them, "default" until switched.
+
+// Moves this connection to partition name.
+func (t *tcpConn) Partition(ctx context.Context, name string) error {
+	_, err := t.cmd(ctx, `partition "`+escape(name)+`"`)
+	return err
+}
+
+// Names of all partitions, "default" first.
+func (t *tcpConn) ListPartitions(ctx context.Context) ([]string, error) {
+	lines, err := t.cmd(ctx, "listpartitions")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, ln := range lines {
+		if name, ok := strings.CutPrefix(ln, "partition: "); ok {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func (t *tcpConn) NewPartition(ctx context.Context, name string) error {
+	_, err := t.cmd(ctx, `newpartition "`+escape(name)+`"`)
+	return err
+}
+
+// Deletes a partition. MPD refuses the default one and any partition a
+// client is still using.
+func (t *tcpConn) DelPartition(ctx context.Context, name string) error {
+	_, err := t.cmd(ctx, `delpartition "`+escape(name)+`"`)
+	return err
+}
+
+// Moves the output called name into this connection's partition.
+func (t *tcpConn) MoveOutput(ctx context.Context, name string) error {
+	_, err := t.cmd(ctx, `moveoutput "`+escape(name)+`"`)
+	return err
+}
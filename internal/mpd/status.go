@@ -0,0 +1,82 @@
+package mpd
+
+import (
+	"context"
+	"time"
+)
+
+// Everything `status` reports, for the CLI. The TUI uses NowPlaying.
+type PlayerStatus struct {
+	State       string // play, pause or stop
+	Volume      int    // -1 when MPD has no mixer
+	Repeat      bool
+	Random      bool
+	Single      string // "0", "1" or "oneshot"
+	Consume     string // "0", "1" or "oneshot"
+	QueueLength int
+	Song        int // queue position, -1 when none
+	SongID      int
+	NextSong    int // -1 when none
+	NextSongID  int
+	Elapsed     time.Duration
+	Duration    time.Duration
+	Bitrate     int    // kbps
+	Audio       string // "samplerate:bits:channels"
+	UpdatingDB  int    // update job id, 0 when idle
+	Error       string
+}
+
+func (t *tcpConn) PlayerStatus(ctx context.Context) (PlayerStatus, error) {
+	lines, err := t.cmd(ctx, "status")
+	if err != nil {
+		return PlayerStatus{}, err
+	}
+	m := kvLower(lines)
+	st := PlayerStatus{
+		State:       m["state"],
+		Volume:      -1,
+		Repeat:      m["repeat"] == "1",
+		Random:      m["random"] == "1",
+		Single:      m["single"],
+		Consume:     m["consume"],
+		QueueLength: parseIntSafe(m["playlistlength"]),
+		Song:        -1,
+		NextSong:    -1,
+		Bitrate:     parseIntSafe(m["bitrate"]),
+		Audio:       m["audio"],
+		UpdatingDB:  parseIntSafe(m["updating_db"]),
+		Error:       m["error"],
+	}
+	if v, ok := m["volume"]; ok {
+		st.Volume = parseIntSafe(v)
+	}
+	if v, ok := m["song"]; ok {
+		st.Song = parseIntSafe(v)
+		st.SongID = parseIntSafe(m["songid"])
+	}
+	if v, ok := m["nextsong"]; ok {
+		st.NextSong = parseIntSafe(v)
+		st.NextSongID = parseIntSafe(m["nextsongid"])
+	}
+	if d, ok := parseSecs(m["elapsed"]); ok {
+		st.Elapsed = d
+	}
+	if d, ok := parseSecs(m["duration"]); ok {
+		st.Duration = d
+	} else if e, d, ok := parseTimePair(m["time"]); ok {
+		st.Elapsed, st.Duration = e, d
+	}
+	return st, nil
+}
+
+// The song at the current queue position; zero Track when there is none.
+func (t *tcpConn) CurrentSong(ctx context.Context) (Track, error) {
+	lines, err := t.cmd(ctx, "currentsong")
+	if err != nil {
+		return Track{}, err
+	}
+	if ts := parseTracks(lines); len(ts) > 0 {
+		return ts[0], nil
+	}
+	return Track{}, nil
+}
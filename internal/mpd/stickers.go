@@ -0,0 +1,88 @@
+package mpd
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Returned by StickerGet when the song has no such sticker.
+var ErrNoSticker = errors.New("no such sticker")
+
+func (t *tcpConn) StickerGet(ctx context.Context, uri, name string) (string, error) {
+	lines, err := t.cmd(ctx, `sticker get song "`+escape(uri)+`" "`+escape(name)+`"`)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "ACK [50@") {
+			return "", ErrNoSticker
+		}
+		return "", err
+	}
+	for _, ln := range lines {
+		if v, ok := strings.CutPrefix(ln, "sticker: "+name+"="); ok {
+			return v, nil
+		}
+	}
+	return "", ErrNoSticker
+}
+
+func (t *tcpConn) StickerSet(ctx context.Context, uri, name, value string) error {
+	_, err := t.cmd(ctx, `sticker set song "`+escape(uri)+`" "`+escape(name)+`" "`+escape(value)+`"`)
+	return err
+}
+
+// Deletes one sticker, or all of the song's stickers when name is "".
+// Deleting a sticker that isn't there is not an error.
+func (t *tcpConn) StickerDelete(ctx context.Context, uri, name string) error {
+	line := `sticker delete song "` + escape(uri) + `"`
+	if name != "" {
+		line += ` "` + escape(name) + `"`
+	}
+	_, err := t.cmd(ctx, line)
+	if err != nil && strings.HasPrefix(err.Error(), "ACK [50@") {
+		return nil
+	}
+	return err
+}
+
+// All stickers of one song, name -> value.
+func (t *tcpConn) StickerList(ctx context.Context, uri string) (map[string]string, error) {
+	lines, err := t.cmd(ctx, `sticker list song "`+escape(uri)+`"`)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]string{}
+	for _, ln := range lines {
+		if kv, ok := strings.CutPrefix(ln, "sticker: "); ok {
+			if k, v, ok := strings.Cut(kv, "="); ok {
+				out[k] = v
+			}
+		}
+	}
+	return out, nil
+}
+
+// Songs under dir ("" for the whole library) that have the named sticker,
+// uri -> value.
+func (t *tcpConn) StickerFind(ctx context.Context, dir, name string) (map[string]string, error) {
+	lines, err := t.cmd(ctx, `sticker find song "`+escape(dir)+`" "`+escape(name)+`"`)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]string{}
+	var uri string
+	for _, ln := range lines {
+		k, v, ok := strings.Cut(ln, ": ")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "file":
+			uri = v
+		case "sticker":
+			if n, val, ok := strings.Cut(v, "="); ok && n == name && uri != "" {
+				out[uri] = val
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,193 @@
+package mpris
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+)
+
+// Time allowed for one MPD command behind a D-Bus call
+const callTimeout = 5 * time.Second
+
+var errNoMPD = errors.New("not connected to MPD")
+
+// Runs fn against MPD, turning failures into D-Bus errors.
+func (b *Bridge) do(fn func(mpd.Conn, context.Context) error) *dbus.Error {
+	conn := b.mpd()
+	if conn == nil {
+		return dbus.MakeFailedError(errNoMPD)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	if err := fn(conn, ctx); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+// org.mpris.MediaPlayer2: there's no window to raise and MPD keeps running.
+type root struct{}
+
+func (root) Raise() *dbus.Error { return nil }
+func (root) Quit() *dbus.Error  { return nil }
+
+var rootProps = map[string]dbus.Variant{
+	"CanQuit":             dbus.MakeVariant(false),
+	"CanRaise":            dbus.MakeVariant(false),
+	"HasTrackList":        dbus.MakeVariant(false),
+	"Identity":            dbus.MakeVariant("gompc"),
+	"SupportedUriSchemes": dbus.MakeVariant([]string{"file", "http", "https"}),
+	"SupportedMimeTypes":  dbus.MakeVariant([]string{}),
+}
+
+// org.mpris.MediaPlayer2.Player
+type player struct{ b *Bridge }
+
+func (p player) Next() *dbus.Error     { return p.b.do(mpd.Conn.Next) }
+func (p player) Previous() *dbus.Error { return p.b.do(mpd.Conn.Prev) }
+func (p player) Stop() *dbus.Error     { return p.b.do(mpd.Conn.Stop) }
+
+func (p player) Pause() *dbus.Error {
+	return p.b.do(func(c mpd.Conn, ctx context.Context) error { return c.Pause(ctx, true) })
+}
+
+func (p player) PlayPause() *dbus.Error { return p.b.do(mpd.Conn.TogglePause) }
+
+func (p player) Play() *dbus.Error { return p.b.do(mpd.Conn.Resume) }
+
+// D-Bus Seek (renamed so it isn't mistaken for io.Seeker): seeks by
+// offset microseconds; past the end moves to the next song.
+func (p player) SeekBy(offset int64) *dbus.Error {
+	p.b.mu.Lock()
+	pos := p.b.position(time.Now()) + time.Duration(offset)*time.Microsecond
+	length := p.b.st.Duration
+	p.b.mu.Unlock()
+	return p.b.do(func(c mpd.Conn, ctx context.Context) error {
+		if length > 0 && pos > length {
+			return c.Next(ctx)
+		}
+		return c.SeekCur(ctx, max(pos, 0))
+	})
+}
+
+// Seeks to pos microseconds, if id is still the current song.
+func (p player) SetPosition(id dbus.ObjectPath, pos int64) *dbus.Error {
+	p.b.mu.Lock()
+	current := trackID(p.b.st.SongID) == id && p.b.cur.URI != ""
+	length := p.b.st.Duration
+	p.b.mu.Unlock()
+	at := time.Duration(pos) * time.Microsecond
+	if !current || at < 0 || (length > 0 && at > length) {
+		return nil // stale request; ignored per spec
+	}
+	return p.b.do(func(c mpd.Conn, ctx context.Context) error { return c.SeekCur(ctx, at) })
+}
+
+// Queues uri and plays it. file:// URLs under the music directory become
+// library paths.
+func (p player) OpenUri(uri string) *dbus.Error {
+	if u, err := url.Parse(uri); err == nil && u.Scheme == "file" && p.b.cfg.MusicDir != "" {
+		if rel, err := filepath.Rel(p.b.cfg.MusicDir, u.Path); err == nil && !strings.HasPrefix(rel, "..") {
+			uri = filepath.ToSlash(rel)
+		}
+	}
+	return p.b.do(func(c mpd.Conn, ctx context.Context) error {
+		id, err := c.QueueAddID(ctx, uri)
+		if err != nil {
+			return err
+		}
+		return c.PlayID(ctx, id)
+	})
+}
+
+// org.freedesktop.DBus.Properties for both interfaces. Position is read
+// live; it never goes out in PropertiesChanged.
+type properties struct{ b *Bridge }
+
+func (p properties) GetAll(iface string) (map[string]dbus.Variant, *dbus.Error) {
+	switch iface {
+	case rootIface:
+		return rootProps, nil
+	case playerIface:
+		p.b.mu.Lock()
+		defer p.b.mu.Unlock()
+		out := make(map[string]dbus.Variant, len(p.b.props)+1)
+		for k, v := range p.b.props {
+			out[k] = v
+		}
+		out["Position"] = dbus.MakeVariant(p.b.position(time.Now()).Microseconds())
+		return out, nil
+	}
+	return nil, dbus.MakeFailedError(errors.New("no such interface " + iface))
+}
+
+func (p properties) Get(iface, name string) (dbus.Variant, *dbus.Error) {
+	all, err := p.GetAll(iface)
+	if err != nil {
+		return dbus.Variant{}, err
+	}
+	v, ok := all[name]
+	if !ok {
+		return dbus.Variant{}, dbus.MakeFailedError(errors.New("no such property " + name))
+	}
+	return v, nil
+}
+
+// Volume, LoopStatus, Shuffle and Rate (which stays 1) are writable.
+func (p properties) Set(iface, name string, v dbus.Variant) *dbus.Error {
+	if iface != playerIface {
+		return dbus.MakeFailedError(errors.New("read-only property " + name))
+	}
+	switch name {
+	case "Volume":
+		vol, ok := v.Value().(float64)
+		if !ok {
+			return badValue(name)
+		}
+		return p.b.do(func(c mpd.Conn, ctx context.Context) error {
+			return c.SetVolume(ctx, int(min(max(vol, 0), 1)*100+0.5))
+		})
+	case "Shuffle":
+		on, ok := v.Value().(bool)
+		if !ok {
+			return badValue(name)
+		}
+		return p.b.do(func(c mpd.Conn, ctx context.Context) error { return c.SetRandom(ctx, on) })
+	case "LoopStatus":
+		s, _ := v.Value().(string)
+		mode, ok := loopModes[s]
+		if !ok {
+			return badValue(name)
+		}
+		return p.b.do(func(c mpd.Conn, ctx context.Context) error {
+			if err := c.SetRepeat(ctx, mode.repeat); err != nil {
+				return err
+			}
+			return c.SetSingle(ctx, mode.single)
+		})
+	case "Rate":
+		return nil
+	}
+	return dbus.MakeFailedError(errors.New("read-only property " + name))
+}
+
+// MPRIS loop statuses as MPD repeat and single
+var loopModes = map[string]struct {
+	repeat bool
+	single string
+}{
+	"None":     {false, "0"},
+	"Track":    {true, "1"},
+	"Playlist": {true, "0"},
+}
+
+func badValue(name string) *dbus.Error {
+	return dbus.MakeFailedError(errors.New("bad value for " + name))
+}
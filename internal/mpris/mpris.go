@@ -0,0 +1,264 @@
+// Package mpris exposes MPD on D-Bus as an MPRIS2 media player, for media
+// keys, desktop widgets and phone remotes like KDE Connect.
+package mpris
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+
+	"github.com/AJMerr/gompc/internal/art"
+	"github.com/AJMerr/gompc/internal/mpd"
+)
+
+const (
+	objectPath  = dbus.ObjectPath("/org/mpris/MediaPlayer2")
+	rootIface   = "org.mpris.MediaPlayer2"
+	playerIface = "org.mpris.MediaPlayer2.Player"
+	propsIface  = "org.freedesktop.DBus.Properties"
+
+	noTrack = dbus.ObjectPath("/org/mpris/MediaPlayer2/TrackList/NoTrack")
+)
+
+type Config struct {
+	Name     string    // bus name is org.mpris.MediaPlayer2.<Name>
+	MusicDir string    // for file:// URLs; optional
+	Art      art.Cache // covers for mpris:artUrl
+}
+
+// Bridge serves MPRIS on a bus for whichever MPD connection is attached.
+type Bridge struct {
+	bus *dbus.Conn
+	cfg Config
+
+	mu    sync.Mutex
+	conn  mpd.Conn // nil while MPD is away
+	st    mpd.PlayerStatus
+	cur   mpd.Track
+	seen  time.Time               // when st was read
+	art   string                  // artUrl of cur
+	props map[string]dbus.Variant // player properties as last announced
+}
+
+// Exports the player on bus and takes its well-known name.
+func New(bus *dbus.Conn, cfg Config) (*Bridge, error) {
+	if cfg.Name == "" {
+		cfg.Name = "gompc"
+	}
+	b := &Bridge{bus: bus, cfg: cfg, st: mpd.PlayerStatus{State: "stop", Song: -1, Volume: -1}}
+	b.props = b.playerProps()
+	exports := []struct {
+		v     any
+		names map[string]string // Go method -> D-Bus method
+		iface string
+	}{
+		{root{}, nil, rootIface},
+		{player{b}, map[string]string{"SeekBy": "Seek"}, playerIface},
+		{properties{b}, nil, propsIface},
+		{introspect.Introspectable(introspection), nil, "org.freedesktop.DBus.Introspectable"},
+	}
+	for _, e := range exports {
+		if err := bus.ExportWithMap(e.v, e.names, objectPath, e.iface); err != nil {
+			return nil, err
+		}
+	}
+	name := rootIface + "." + cfg.Name
+	reply, err := bus.RequestName(name, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		return nil, err
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		return nil, fmt.Errorf("%s is already taken", name)
+	}
+	return b, nil
+}
+
+func (b *Bridge) BusName() string { return rootIface + "." + b.cfg.Name }
+
+// Switches to conn (nil when MPD went away) and announces the change.
+func (b *Bridge) Attach(ctx context.Context, conn mpd.Conn) error {
+	b.mu.Lock()
+	b.conn = conn
+	b.mu.Unlock()
+	if conn == nil {
+		b.update(mpd.PlayerStatus{State: "stop", Song: -1, Volume: -1}, mpd.Track{}, "")
+		return nil
+	}
+	return b.Refresh(ctx)
+}
+
+// Re-reads the player from MPD and emits PropertiesChanged for what
+// changed, plus Seeked when the position jumped.
+func (b *Bridge) Refresh(ctx context.Context) error {
+	conn := b.mpd()
+	if conn == nil {
+		return nil
+	}
+	st, err := conn.PlayerStatus(ctx)
+	if err != nil {
+		return err
+	}
+	cur, err := conn.CurrentSong(ctx)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	artURL, sameSong := b.art, cur.URI == b.cur.URI
+	b.mu.Unlock()
+	if !sameSong {
+		artURL = ""
+		if file := b.cfg.Art.Path(ctx, conn, cur.URI); file != "" {
+			artURL = (&url.URL{Scheme: "file", Path: file}).String()
+		}
+	}
+	b.update(st, cur, artURL)
+	return nil
+}
+
+func (b *Bridge) update(st mpd.PlayerStatus, cur mpd.Track, artURL string) {
+	b.mu.Lock()
+	expected := b.position(time.Now())
+	sameSong := st.SongID == b.st.SongID && cur.URI == b.cur.URI
+	b.st, b.cur, b.art, b.seen = st, cur, artURL, time.Now()
+	props := b.playerProps()
+	changed := map[string]dbus.Variant{}
+	for k, v := range props {
+		if !reflect.DeepEqual(b.props[k].Value(), v.Value()) {
+			changed[k] = v
+		}
+	}
+	b.props = props
+	b.mu.Unlock()
+
+	if len(changed) > 0 {
+		_ = b.bus.Emit(objectPath, propsIface+".PropertiesChanged", playerIface, changed, []string{})
+	}
+	if jump := st.Elapsed - expected; sameSong && st.State != "stop" && (jump > 1500*time.Millisecond || jump < -1500*time.Millisecond) {
+		_ = b.bus.Emit(objectPath, playerIface+".Seeked", st.Elapsed.Microseconds())
+	}
+}
+
+func (b *Bridge) mpd() mpd.Conn {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.conn
+}
+
+// Where playback should be now, going by the last status. Needs b.mu.
+func (b *Bridge) position(now time.Time) time.Duration {
+	pos := b.st.Elapsed
+	if b.st.State == "play" && !b.seen.IsZero() {
+		pos += now.Sub(b.seen)
+	}
+	if b.st.Duration > 0 {
+		pos = min(pos, b.st.Duration)
+	}
+	return pos
+}
+
+// Player properties that go out in PropertiesChanged (not Position).
+// Needs b.mu.
+func (b *Bridge) playerProps() map[string]dbus.Variant {
+	status := map[string]string{"play": "Playing", "pause": "Paused"}[b.st.State]
+	if status == "" {
+		status = "Stopped"
+	}
+	loop := "None"
+	switch {
+	case b.st.Repeat && b.st.Single == "1":
+		loop = "Track"
+	case b.st.Repeat:
+		loop = "Playlist"
+	}
+	hasSong := b.cur.URI != ""
+	connected := b.conn != nil
+	return map[string]dbus.Variant{
+		"PlaybackStatus": dbus.MakeVariant(status),
+		"LoopStatus":     dbus.MakeVariant(loop),
+		"Shuffle":        dbus.MakeVariant(b.st.Random),
+		"Volume":         dbus.MakeVariant(float64(max(b.st.Volume, 0)) / 100),
+		"Metadata":       dbus.MakeVariant(b.metadata()),
+		"Rate":           dbus.MakeVariant(1.0),
+		"MinimumRate":    dbus.MakeVariant(1.0),
+		"MaximumRate":    dbus.MakeVariant(1.0),
+		"CanGoNext":      dbus.MakeVariant(connected && b.st.NextSong >= 0),
+		"CanGoPrevious":  dbus.MakeVariant(connected && hasSong),
+		"CanPlay":        dbus.MakeVariant(connected && b.st.QueueLength > 0),
+		"CanPause":       dbus.MakeVariant(connected && hasSong),
+		"CanSeek":        dbus.MakeVariant(connected && b.st.Duration > 0),
+		"CanControl":     dbus.MakeVariant(true),
+	}
+}
+
+// xesam metadata of the current song. Needs b.mu.
+func (b *Bridge) metadata() map[string]dbus.Variant {
+	t := b.cur
+	if t.URI == "" {
+		return map[string]dbus.Variant{"mpris:trackid": dbus.MakeVariant(noTrack)}
+	}
+	md := map[string]dbus.Variant{
+		"mpris:trackid": dbus.MakeVariant(trackID(b.st.SongID)),
+		"mpris:length":  dbus.MakeVariant(t.Duration.Microseconds()),
+		"xesam:title":   dbus.MakeVariant(nz(t.Title, path.Base(t.URI))),
+	}
+	if u := b.songURL(t.URI); u != "" {
+		md["xesam:url"] = dbus.MakeVariant(u)
+	}
+	if t.Artist != "" {
+		md["xesam:artist"] = dbus.MakeVariant([]string{t.Artist})
+	}
+	if t.Album != "" {
+		md["xesam:album"] = dbus.MakeVariant(t.Album)
+	}
+	if t.Genre != "" {
+		md["xesam:genre"] = dbus.MakeVariant([]string{t.Genre})
+	}
+	if t.Composer != "" {
+		md["xesam:composer"] = dbus.MakeVariant([]string{t.Composer})
+	}
+	if t.TrackNo > 0 {
+		md["xesam:trackNumber"] = dbus.MakeVariant(int32(t.TrackNo))
+	}
+	if t.DiscNo > 0 {
+		md["xesam:discNumber"] = dbus.MakeVariant(int32(t.DiscNo))
+	}
+	if t.Year > 0 {
+		md["xesam:contentCreated"] = dbus.MakeVariant(fmt.Sprintf("%04d", t.Year))
+	}
+	if b.art != "" {
+		md["mpris:artUrl"] = dbus.MakeVariant(b.art)
+	}
+	return md
+}
+
+func nz(s, def string) string {
+	if strings.TrimSpace(s) == "" {
+		return def
+	}
+	return s
+}
+
+func trackID(songID int) dbus.ObjectPath {
+	return dbus.ObjectPath(fmt.Sprintf("/org/gompc/track/%d", songID))
+}
+
+// file:// under MusicDir for library songs; streams keep their URL. ""
+// without a MusicDir.
+func (b *Bridge) songURL(uri string) string {
+	if strings.Contains(uri, "://") {
+		return uri
+	}
+	if b.cfg.MusicDir == "" {
+		return ""
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.Join(b.cfg.MusicDir, uri)}).String()
+}
@@ -0,0 +1,255 @@
+package mpris
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+)
+
+// Starts a private session bus, or skips the test when there's no
+// dbus-daemon to start.
+func privateBus(t *testing.T) string {
+	t.Helper()
+	bin, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not installed")
+	}
+	cmd := exec.Command(bin, "--session", "--nofork", "--print-address")
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	addr, err := bufio.NewReader(out).ReadString('\n')
+	if err != nil {
+		t.Skipf("dbus-daemon gave no address: %v", err)
+	}
+	return strings.TrimSpace(addr)
+}
+
+func connect(t *testing.T, addr string) *dbus.Conn {
+	t.Helper()
+	c, err := dbus.Connect(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// An MPD that records the commands it gets; anything else panics.
+type fakeConn struct {
+	mpd.Conn
+
+	mu    sync.Mutex
+	st    mpd.PlayerStatus
+	cur   mpd.Track
+	calls []string
+}
+
+func (f *fakeConn) record(call string) error {
+	f.mu.Lock()
+	f.calls = append(f.calls, call)
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *fakeConn) took() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	calls := f.calls
+	f.calls = nil
+	return calls
+}
+
+func (f *fakeConn) PlayerStatus(context.Context) (mpd.PlayerStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.st, nil
+}
+
+func (f *fakeConn) CurrentSong(context.Context) (mpd.Track, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.cur, nil
+}
+
+func (f *fakeConn) TogglePause(context.Context) error { return f.record("pause") }
+func (f *fakeConn) Next(context.Context) error        { return f.record("next") }
+
+func (f *fakeConn) SeekCur(_ context.Context, pos time.Duration) error {
+	return f.record(fmt.Sprint("seekcur ", pos))
+}
+
+func (f *fakeConn) SetRepeat(_ context.Context, on bool) error {
+	return f.record(fmt.Sprint("repeat ", on))
+}
+
+func (f *fakeConn) SetSingle(_ context.Context, mode string) error {
+	return f.record("single " + mode)
+}
+
+// The next PropertiesChanged on sigs, as the changed properties.
+func nextChange(t *testing.T, sigs <-chan *dbus.Signal) map[string]dbus.Variant {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case sig := <-sigs:
+			if sig.Name != propsIface+".PropertiesChanged" || sig.Path != objectPath {
+				continue
+			}
+			if iface, _ := sig.Body[0].(string); iface != playerIface {
+				t.Errorf("PropertiesChanged for %s", iface)
+			}
+			changed, _ := sig.Body[1].(map[string]dbus.Variant)
+			return changed
+		case <-timeout:
+			t.Fatal("no PropertiesChanged")
+			return nil
+		}
+	}
+}
+
+func TestBridge(t *testing.T) {
+	addr := privateBus(t)
+	server, client := connect(t, addr), connect(t, addr)
+	ctx := context.Background()
+
+	b, err := New(server, Config{Name: "gompctest", MusicDir: "/music"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var owner string
+	if err := client.BusObject().Call("org.freedesktop.DBus.GetNameOwner", 0, b.BusName()).Store(&owner); err != nil {
+		t.Fatalf("%s has no owner: %v", b.BusName(), err)
+	}
+	if owner != server.Names()[0] {
+		t.Errorf("%s owned by %s, want %s", b.BusName(), owner, server.Names()[0])
+	}
+	if _, err := New(connect(t, addr), Config{Name: "gompctest"}); err == nil {
+		t.Errorf("a second bridge took the same name")
+	}
+
+	if err := client.AddMatchSignal(dbus.WithMatchObjectPath(objectPath), dbus.WithMatchInterface(propsIface)); err != nil {
+		t.Fatal(err)
+	}
+	sigs := make(chan *dbus.Signal, 16)
+	client.Signal(sigs)
+
+	fake := &fakeConn{
+		st: mpd.PlayerStatus{
+			State: "pause", Volume: 40, Repeat: true, Single: "1", Random: true,
+			QueueLength: 3, Song: 0, SongID: 7, NextSong: 1,
+			Elapsed: time.Minute, Duration: 200 * time.Second,
+		},
+		cur: mpd.Track{URI: "jazz/so what.flac", Title: "So What", Artist: "Miles Davis", Album: "Kind of Blue", Duration: 200 * time.Second},
+	}
+	if err := b.Attach(ctx, fake); err != nil {
+		t.Fatal(err)
+	}
+	changed := nextChange(t, sigs)
+	for _, k := range []string{"Metadata", "PlaybackStatus", "LoopStatus", "Shuffle", "Volume", "CanGoNext"} {
+		if _, ok := changed[k]; !ok {
+			t.Errorf("attaching didn't announce %s", k)
+		}
+	}
+
+	obj := client.Object(b.BusName(), objectPath)
+	prop := func(name string) any {
+		t.Helper()
+		v, err := obj.GetProperty(playerIface + "." + name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return v.Value()
+	}
+	md, _ := prop("Metadata").(map[string]dbus.Variant)
+	for k, want := range map[string]any{
+		"mpris:trackid": dbus.ObjectPath("/org/gompc/track/7"),
+		"mpris:length":  int64(200_000_000),
+		"xesam:title":   "So What",
+		"xesam:artist":  []string{"Miles Davis"},
+		"xesam:album":   "Kind of Blue",
+		"xesam:url":     "file:///music/jazz/so%20what.flac",
+	} {
+		if got := md[k].Value(); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("Metadata[%s] = %v, want %v", k, got, want)
+		}
+	}
+	if got := prop("LoopStatus"); got != "Track" {
+		t.Errorf("LoopStatus = %v, want Track", got)
+	}
+	if got := prop("Shuffle"); got != true {
+		t.Errorf("Shuffle = %v, want true", got)
+	}
+	if got := prop("PlaybackStatus"); got != "Paused" {
+		t.Errorf("PlaybackStatus = %v, want Paused", got)
+	}
+
+	// Methods reach MPD.
+	for _, tc := range []struct {
+		method string
+		args   []any
+		want   string
+	}{
+		{"PlayPause", nil, "[pause]"},
+		{"Next", nil, "[next]"},
+		{"Seek", []any{int64(10_000_000)}, "[seekcur 1m10s]"},
+		{"Seek", []any{int64(-120_000_000)}, "[seekcur 0s]"},
+		{"Seek", []any{int64(500_000_000)}, "[next]"},
+	} {
+		if err := obj.Call(playerIface+"."+tc.method, 0, tc.args...).Err; err != nil {
+			t.Errorf("%s%v: %v", tc.method, tc.args, err)
+		}
+		if got := fmt.Sprint(fake.took()); got != tc.want {
+			t.Errorf("%s%v sent %s, want %s", tc.method, tc.args, got, tc.want)
+		}
+	}
+	if err := obj.SetProperty(playerIface+".LoopStatus", dbus.MakeVariant("Playlist")); err != nil {
+		t.Errorf("set LoopStatus: %v", err)
+	}
+	if got := fmt.Sprint(fake.took()); got != "[repeat true single 0]" {
+		t.Errorf("set LoopStatus sent %s", got)
+	}
+
+	// Only what changed goes out.
+	fake.mu.Lock()
+	fake.st.Random = false
+	fake.st.Repeat = false
+	fake.mu.Unlock()
+	if err := b.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	changed = nextChange(t, sigs)
+	if len(changed) != 2 || changed["Shuffle"].Value() != false || changed["LoopStatus"].Value() != "None" {
+		t.Errorf("PropertiesChanged = %v, want Shuffle false and LoopStatus None", changed)
+	}
+
+	// Without MPD, calls fail rather than hang.
+	if err := b.Attach(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	changed = nextChange(t, sigs)
+	if changed["PlaybackStatus"].Value() != "Stopped" {
+		t.Errorf("detaching: PlaybackStatus = %v", changed["PlaybackStatus"])
+	}
+	if err := obj.Call(playerIface+".PlayPause", 0).Err; err == nil {
+		t.Errorf("PlayPause without MPD succeeded")
+	}
+}
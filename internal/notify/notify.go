@@ -0,0 +1,197 @@
+// Package notify pops up a desktop notification when a new song starts,
+// over D-Bus (org.freedesktop.Notifications) or notify-send without it.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/url"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/AJMerr/gompc/internal/art"
+	"github.com/AJMerr/gompc/internal/mpd"
+)
+
+type Config struct {
+	Summary *template.Template // executed with the mpd.Track
+	Body    *template.Template
+
+	// At most one notification per MinInterval; songs skipped through
+	// faster are coalesced into one for the last of them.
+	MinInterval time.Duration
+	Timeout     time.Duration // how long it stays up; 0 = the server's default
+	Art         art.Cache
+}
+
+var (
+	DefaultSummary = "{{.Title}}"
+	DefaultBody    = "{{.Artist}}{{with .Album}}\n{{.}}{{end}}"
+)
+
+type message struct {
+	summary, body, icon string
+}
+
+// Notifier watches player snapshots for song changes. Safe for concurrent
+// use.
+type Notifier struct {
+	cfg Config
+
+	mu      sync.Mutex
+	seen    bool
+	songID  int
+	uri     string
+	last    time.Time // when the last notification went out
+	pending *message  // waiting out MinInterval
+	id      uint32    // the popup to replace
+	bus     *dbus.Conn
+}
+
+func New(cfg Config) *Notifier {
+	if cfg.Summary == nil {
+		cfg.Summary = template.Must(template.New("summary").Parse(DefaultSummary))
+	}
+	if cfg.Body == nil {
+		cfg.Body = template.Must(template.New("body").Parse(DefaultBody))
+	}
+	return &Notifier{cfg: cfg}
+}
+
+// Notifies when np is a different song than last time and not stopped.
+// The first snapshot only sets the baseline. conn supplies tags and cover.
+func (n *Notifier) Observe(ctx context.Context, conn mpd.Conn, np mpd.NowPlaying) error {
+	n.mu.Lock()
+	changed := n.seen && (np.SongID != n.songID || np.URI != n.uri)
+	n.seen, n.songID, n.uri = true, np.SongID, np.URI
+	n.mu.Unlock()
+	if !changed || np.URI == "" || np.State == "stop" {
+		return nil
+	}
+	return n.Notify(ctx, conn)
+}
+
+// Notifies about the current song, subject to MinInterval.
+func (n *Notifier) Notify(ctx context.Context, conn mpd.Conn) error {
+	t, err := conn.CurrentSong(ctx)
+	if err != nil || t.URI == "" {
+		return err
+	}
+	msg, err := n.render(t)
+	if err != nil {
+		return err
+	}
+	msg.icon = n.cfg.Art.Path(ctx, conn, t.URI)
+
+	n.mu.Lock()
+	wait := n.cfg.MinInterval - time.Since(n.last)
+	if wait > 0 {
+		if n.pending == nil {
+			time.AfterFunc(wait, n.flush)
+		}
+		n.pending = &msg
+		n.mu.Unlock()
+		return nil
+	}
+	n.last = time.Now()
+	n.mu.Unlock()
+	return n.send(ctx, msg)
+}
+
+// Sends the newest song held back by MinInterval.
+func (n *Notifier) flush() {
+	n.mu.Lock()
+	msg := n.pending
+	n.pending = nil
+	n.last = time.Now()
+	n.mu.Unlock()
+	if msg != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = n.send(ctx, *msg)
+	}
+}
+
+func (n *Notifier) render(t mpd.Track) (message, error) {
+	var summary, body bytes.Buffer
+	if err := n.cfg.Summary.Execute(&summary, t); err != nil {
+		return message{}, err
+	}
+	if err := n.cfg.Body.Execute(&body, t); err != nil {
+		return message{}, err
+	}
+	msg := message{summary: strings.TrimSpace(summary.String()), body: strings.TrimSpace(body.String())}
+	if msg.summary == "" {
+		msg.summary = path.Base(t.URI)
+	}
+	return msg, nil
+}
+
+// Over D-Bus when there's a session bus, else notify-send.
+func (n *Notifier) send(ctx context.Context, msg message) error {
+	err := n.sendDBus(ctx, msg)
+	if err == nil {
+		return nil
+	}
+	if _, lookErr := exec.LookPath("notify-send"); lookErr != nil {
+		return err
+	}
+	return sendNotifySend(ctx, msg, n.cfg.Timeout)
+}
+
+func (n *Notifier) sendDBus(ctx context.Context, msg message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.bus == nil {
+		bus, err := dbus.ConnectSessionBus()
+		if err != nil {
+			return err
+		}
+		n.bus = bus
+	}
+	hints := map[string]dbus.Variant{"category": dbus.MakeVariant("x-gnome.music")}
+	if msg.icon != "" {
+		hints["image-path"] = dbus.MakeVariant((&url.URL{Scheme: "file", Path: msg.icon}).String())
+	}
+	expire := int32(-1)
+	if n.cfg.Timeout > 0 {
+		expire = int32(n.cfg.Timeout.Milliseconds())
+	}
+	obj := n.bus.Object("org.freedesktop.Notifications", "/org/freedesktop/Notifications")
+	call := obj.CallWithContext(ctx, "org.freedesktop.Notifications.Notify", 0,
+		"gompc", n.id, "", msg.summary, escapeMarkup(msg.body), []string{}, hints, expire)
+	if call.Err != nil {
+		var derr dbus.Error
+		if !errors.As(call.Err, &derr) {
+			n.bus.Close() // connection trouble; dial again next time
+			n.bus = nil
+		}
+		return call.Err
+	}
+	return call.Store(&n.id)
+}
+
+func sendNotifySend(ctx context.Context, msg message, timeout time.Duration) error {
+	args := []string{"--app-name=gompc", "--category=x-gnome.music"}
+	if msg.icon != "" {
+		args = append(args, "--icon="+msg.icon)
+	}
+	if timeout > 0 {
+		args = append(args, "--expire-time="+strconv.FormatInt(timeout.Milliseconds(), 10))
+	}
+	args = append(args, "--", msg.summary, escapeMarkup(msg.body))
+	return exec.CommandContext(ctx, "notify-send", args...).Run()
+}
+
+// Bodies may be read as markup.
+func escapeMarkup(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,103 @@
+// Package plays turns a stream of player snapshots into finished and
+// skipped plays, for play counts, history and scrobbling.
+package plays
+
+import (
+	"time"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+)
+
+// One play of a song, reported when the player moves on from it.
+type Play struct {
+	Song     mpd.NowPlaying // as last seen
+	Started  time.Time      // when it started: first seen, less the position then
+	Ended    time.Time
+	From     time.Duration // position when first seen
+	Played   time.Duration // furthest position reached
+	Heard    time.Duration // time spent playing it; seeks add nothing
+	Finished bool          // got past 90% after hearing at least half
+	Skipped  bool          // left before 90%
+}
+
+// Tracker follows the current song between snapshots. Snapshots can be
+// sparse (one per idle event): while playing, the position is carried
+// forward by the wall clock. The zero value is ready to use.
+type Tracker struct {
+	cur      mpd.NowPlaying
+	seenAt   time.Time
+	started  time.Time
+	from     time.Duration
+	furthest time.Duration
+	heard    time.Duration
+	active   bool
+}
+
+// Feeds a status snapshot taken at at. When it shows the player has left
+// the previous song (another song, a restart of the same one, or stop),
+// that play is returned.
+func (t *Tracker) Observe(np mpd.NowPlaying, at time.Time) (Play, bool) {
+	if t.active {
+		t.furthest = max(t.furthest, t.position(at))
+		t.heard += t.playedSince(at)
+	}
+	playing := np.URI != "" && np.State != "stop"
+	restarted := np.Elapsed < 2*time.Second && t.furthest > 5*time.Second
+	same := t.active && playing && !restarted &&
+		np.URI == t.cur.URI && np.SongID == t.cur.SongID
+	if same {
+		t.cur, t.seenAt = np, at
+		t.furthest = max(t.furthest, np.Elapsed)
+		return Play{}, false
+	}
+
+	p, ended := t.end(at)
+	if playing {
+		t.cur, t.seenAt, t.started = np, at, at.Add(-np.Elapsed)
+		t.from, t.furthest, t.heard, t.active = np.Elapsed, np.Elapsed, 0, true
+	}
+	return p, ended
+}
+
+// Where the current song should be at time at.
+func (t *Tracker) position(at time.Time) time.Duration {
+	pos := t.cur.Elapsed
+	if t.cur.State == "play" && at.After(t.seenAt) {
+		pos += at.Sub(t.seenAt)
+	}
+	if d := t.cur.Duration; d > 0 && pos > d {
+		pos = d
+	}
+	return pos
+}
+
+// Wall-clock time spent playing since the last snapshot, no more than was
+// left of the song then. A seek moves the position, not this.
+func (t *Tracker) playedSince(at time.Time) time.Duration {
+	if t.cur.State != "play" || !at.After(t.seenAt) {
+		return 0
+	}
+	dt := at.Sub(t.seenAt)
+	if d := t.cur.Duration; d > 0 {
+		dt = min(dt, max(0, d-t.cur.Elapsed))
+	}
+	return dt
+}
+
+func (t *Tracker) end(at time.Time) (Play, bool) {
+	if !t.active {
+		return Play{}, false
+	}
+	t.active = false
+	d := t.cur.Duration
+	return Play{
+		Song:     t.cur,
+		Started:  t.started,
+		Ended:    at,
+		From:     t.from,
+		Played:   t.furthest,
+		Heard:    t.heard,
+		Finished: d > 0 && t.furthest >= d*9/10 && t.heard >= d/2,
+		Skipped:  d > 0 && t.furthest < d*9/10,
+	}, true
+}
@@ -0,0 +1,107 @@
+package scrobble
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const DefaultListenBrainzURL = "https://api.listenbrainz.org"
+
+// ListenBrainz speaks the ListenBrainz submission API, which other servers
+// (Maloja, Koito, ...) implement too.
+type ListenBrainz struct {
+	URL   string // API root; DefaultListenBrainzURL when empty
+	Token string // user token from the profile page
+	HTTP  *http.Client
+}
+
+func (lb *ListenBrainz) Name() string { return "listenbrainz" }
+
+type lbListen struct {
+	ListenedAt int64 `json:"listened_at,omitempty"`
+	Track      struct {
+		Artist string         `json:"artist_name"`
+		Title  string         `json:"track_name"`
+		Album  string         `json:"release_name,omitempty"`
+		Info   map[string]any `json:"additional_info"`
+	} `json:"track_metadata"`
+}
+
+func lbPayload(ls []Listen, stamp bool) []lbListen {
+	out := make([]lbListen, len(ls))
+	for i, l := range ls {
+		if stamp {
+			out[i].ListenedAt = l.At.Unix()
+		}
+		out[i].Track.Artist = l.Artist
+		out[i].Track.Title = l.Title
+		out[i].Track.Album = l.Album
+		out[i].Track.Info = map[string]any{"media_player": "gompc", "submission_client": "gompc"}
+		if l.DurationMS > 0 {
+			out[i].Track.Info["duration_ms"] = l.DurationMS
+		}
+	}
+	return out
+}
+
+func (lb *ListenBrainz) NowPlaying(ctx context.Context, l Listen) error {
+	return lb.submit(ctx, "playing_now", lbPayload([]Listen{l}, false))
+}
+
+func (lb *ListenBrainz) Submit(ctx context.Context, ls []Listen) error {
+	kind := "import"
+	if len(ls) == 1 {
+		kind = "single"
+	}
+	return lb.submit(ctx, kind, lbPayload(ls, true))
+}
+
+func (lb *ListenBrainz) submit(ctx context.Context, kind string, payload []lbListen) error {
+	body, err := json.Marshal(map[string]any{"listen_type": kind, "payload": payload})
+	if err != nil {
+		return err
+	}
+	base := lb.URL
+	if base == "" {
+		base = DefaultListenBrainzURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(base, "/")+"/1/submit-listens", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Token "+lb.Token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient(lb.HTTP).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	var e struct {
+		Error string `json:"error"`
+	}
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if json.Unmarshal(b, &e) != nil || e.Error == "" {
+		e.Error = strings.TrimSpace(string(b))
+	}
+	if resp.StatusCode == http.StatusBadRequest {
+		return &RejectedError{Service: lb.Name(), Msg: e.Error}
+	}
+	return fmt.Errorf("listenbrainz: %s: %s", resp.Status, e.Error)
+}
+
+// c, or a client with a sane timeout.
+func httpClient(c *http.Client) *http.Client {
+	if c != nil {
+		return c
+	}
+	return &http.Client{Timeout: 15 * time.Second}
+}
@@ -0,0 +1,113 @@
+package scrobble
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type lbRequest struct {
+	Auth string
+	Body struct {
+		ListenType string     `json:"listen_type"`
+		Payload    []lbListen `json:"payload"`
+	}
+}
+
+// A stand-in ListenBrainz that records requests and answers with status.
+func lbServer(t *testing.T, status int, reply string) (*ListenBrainz, *[]lbRequest) {
+	t.Helper()
+	var got []lbRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/1/submit-listens" {
+			t.Errorf("request %s %s", r.Method, r.URL.Path)
+		}
+		var req lbRequest
+		req.Auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&req.Body); err != nil {
+			t.Errorf("body: %v", err)
+		}
+		got = append(got, req)
+		w.WriteHeader(status)
+		w.Write([]byte(reply))
+	}))
+	t.Cleanup(srv.Close)
+	return &ListenBrainz{URL: srv.URL + "/", Token: "tok"}, &got
+}
+
+var testListen = Listen{
+	At:         time.Unix(1700000000, 0),
+	Artist:     "Miles Davis",
+	Title:      "So What",
+	Album:      "Kind of Blue",
+	DurationMS: 545000,
+}
+
+func TestListenBrainzSubmit(t *testing.T) {
+	lb, got := lbServer(t, http.StatusOK, `{"status":"ok"}`)
+	second := testListen
+	second.Title = "Freddie Freeloader"
+	if err := lb.Submit(context.Background(), []Listen{testListen, second}); err != nil {
+		t.Fatal(err)
+	}
+	if err := lb.Submit(context.Background(), []Listen{testListen}); err != nil {
+		t.Fatal(err)
+	}
+	if len(*got) != 2 {
+		t.Fatalf("got %d requests, want 2", len(*got))
+	}
+	req := (*got)[0]
+	if req.Auth != "Token tok" {
+		t.Errorf("Authorization = %q", req.Auth)
+	}
+	if req.Body.ListenType != "import" || (*got)[1].Body.ListenType != "single" {
+		t.Errorf("listen types %q, %q; want import, single", req.Body.ListenType, (*got)[1].Body.ListenType)
+	}
+	p := req.Body.Payload
+	if len(p) != 2 {
+		t.Fatalf("payload has %d listens, want 2", len(p))
+	}
+	if p[0].ListenedAt != 1700000000 || p[0].Track.Artist != "Miles Davis" ||
+		p[0].Track.Title != "So What" || p[0].Track.Album != "Kind of Blue" {
+		t.Errorf("first listen = %+v", p[0])
+	}
+	if ms, _ := p[0].Track.Info["duration_ms"].(float64); ms != 545000 {
+		t.Errorf("duration_ms = %v", p[0].Track.Info["duration_ms"])
+	}
+	if p[1].Track.Title != "Freddie Freeloader" {
+		t.Errorf("second title = %q", p[1].Track.Title)
+	}
+}
+
+func TestListenBrainzNowPlaying(t *testing.T) {
+	lb, got := lbServer(t, http.StatusOK, `{"status":"ok"}`)
+	if err := lb.NowPlaying(context.Background(), testListen); err != nil {
+		t.Fatal(err)
+	}
+	req := (*got)[0]
+	if req.Body.ListenType != "playing_now" {
+		t.Errorf("listen_type = %q", req.Body.ListenType)
+	}
+	if req.Body.Payload[0].ListenedAt != 0 {
+		t.Errorf("playing_now has listened_at %d", req.Body.Payload[0].ListenedAt)
+	}
+}
+
+func TestListenBrainzErrors(t *testing.T) {
+	lb, _ := lbServer(t, http.StatusBadRequest, `{"code":400,"error":"track_name missing"}`)
+	err := lb.Submit(context.Background(), []Listen{testListen})
+	var rej *RejectedError
+	if !errors.As(err, &rej) || rej.Msg != "track_name missing" {
+		t.Errorf("400: got %v, want RejectedError", err)
+	}
+
+	lb, _ = lbServer(t, http.StatusUnauthorized, `{"code":401,"error":"Invalid authorization token."}`)
+	err = lb.Submit(context.Background(), []Listen{testListen})
+	if err == nil || errors.As(err, &rej) {
+		t.Errorf("401: got %v, want a retryable error", err)
+	}
+}
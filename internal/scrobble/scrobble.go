@@ -0,0 +1,67 @@
+// Package scrobble submits plays to Last.fm and ListenBrainz style services.
+// Listens are spooled on disk first and only dropped once a service takes
+// them, so nothing is lost while offline.
+package scrobble
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+	"github.com/AJMerr/gompc/internal/plays"
+)
+
+// One song, as submitted.
+type Listen struct {
+	At         time.Time `json:"at"` // when it started playing
+	Artist     string    `json:"artist"`
+	Title      string    `json:"title"`
+	Album      string    `json:"album,omitempty"`
+	DurationMS int64     `json:"duration_ms,omitempty"`
+}
+
+func (l Listen) Duration() time.Duration { return time.Duration(l.DurationMS) * time.Millisecond }
+
+// The listen of np, which started playing at started (now less elapsed).
+func FromSong(np mpd.NowPlaying, started time.Time) Listen {
+	return Listen{
+		At:         started.Truncate(time.Second),
+		Artist:     np.Artist,
+		Title:      np.Title,
+		Album:      np.Album,
+		DurationMS: np.Duration.Milliseconds(),
+	}
+}
+
+// Both services need an artist and a title.
+func Submittable(np mpd.NowPlaying) bool { return np.Artist != "" && np.Title != "" }
+
+// The usual scrobble rule: a song of 30s or more, heard for half its length
+// or 4 minutes, whichever comes first.
+func Counts(p plays.Play) bool {
+	d := p.Song.Duration
+	return Submittable(p.Song) && d >= 30*time.Second &&
+		p.Heard >= min(d/2, 4*time.Minute)
+}
+
+// A scrobbling service.
+type Service interface {
+	Name() string
+	NowPlaying(ctx context.Context, l Listen) error
+	Submit(ctx context.Context, ls []Listen) error // at most MaxBatch
+}
+
+// Listens per Submit call; Last.fm's limit, and well under ListenBrainz's.
+const MaxBatch = 50
+
+// The service refused the listens themselves; sending them again won't
+// help. Other errors (network, rate limits, bad credentials) are retried.
+type RejectedError struct {
+	Service string
+	Msg     string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("%s rejected the listens: %s", e.Service, e.Msg)
+}
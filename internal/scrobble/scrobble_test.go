@@ -0,0 +1,115 @@
+package scrobble
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+	"github.com/AJMerr/gompc/internal/plays"
+)
+
+func TestCounts(t *testing.T) {
+	song := func(d time.Duration) mpd.NowPlaying {
+		return mpd.NowPlaying{Artist: "a", Title: "t", Duration: d}
+	}
+	for _, tc := range []struct {
+		name  string
+		song  mpd.NowPlaying
+		heard time.Duration
+		want  bool
+	}{
+		{"half heard", song(3 * time.Minute), 90 * time.Second, true},
+		{"just short of half", song(3 * time.Minute), 89 * time.Second, false},
+		{"4 minutes of a long song", song(20 * time.Minute), 4 * time.Minute, true},
+		{"under 4 minutes of a long song", song(20 * time.Minute), 3 * time.Minute, false},
+		{"too short", song(29 * time.Second), 29 * time.Second, false},
+		{"exactly 30s", song(30 * time.Second), 15 * time.Second, true},
+		{"no artist", mpd.NowPlaying{Title: "t", Duration: time.Minute}, time.Minute, false},
+		{"no title", mpd.NowPlaying{Artist: "a", Duration: time.Minute}, time.Minute, false},
+		{"unknown length", song(0), 10 * time.Minute, false},
+	} {
+		p := plays.Play{Song: tc.song, Played: tc.heard, Heard: tc.heard}
+		if got := Counts(p); got != tc.want {
+			t.Errorf("%s: Counts = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// Plays as the tracker reports them: seeking moves the position but only
+// time spent playing counts.
+func TestCountsTracked(t *testing.T) {
+	t0 := time.Unix(1700000000, 0)
+	np := func(state string, elapsed time.Duration) mpd.NowPlaying {
+		return mpd.NowPlaying{URI: "s.flac", SongID: 1, Artist: "a", Title: "t",
+			State: state, Elapsed: elapsed, Duration: 3 * time.Minute}
+	}
+	type snap struct {
+		after time.Duration // since t0
+		np    mpd.NowPlaying
+	}
+	for _, tc := range []struct {
+		name     string
+		snaps    []snap // the last one moves off the song
+		want     bool
+		finished bool
+	}{
+		{
+			name:  "played through",
+			snaps: []snap{{0, np("play", 0)}, {3 * time.Minute, np("stop", 0)}},
+			want:  true, finished: true,
+		},
+		{
+			name: "seeked to the end",
+			snaps: []snap{
+				{0, np("play", 0)},
+				{5 * time.Second, np("play", 170*time.Second)},
+				{15 * time.Second, np("stop", 0)},
+			},
+		},
+		{
+			name: "seeked forward, then heard half",
+			snaps: []snap{
+				{0, np("play", 0)},
+				{10 * time.Second, np("play", 80*time.Second)},
+				{110 * time.Second, np("stop", 0)},
+			},
+			want: true, finished: true,
+		},
+		{
+			name: "paused halfway for an hour",
+			snaps: []snap{
+				{0, np("play", 0)},
+				{60 * time.Second, np("pause", 60*time.Second)},
+				{time.Hour, np("play", 60*time.Second)},
+				{time.Hour + 20*time.Second, np("stop", 0)},
+			},
+		},
+	} {
+		var tr plays.Tracker
+		var p plays.Play
+		var ended bool
+		for _, s := range tc.snaps {
+			p, ended = tr.Observe(s.np, t0.Add(s.after))
+		}
+		if !ended {
+			t.Errorf("%s: no play reported", tc.name)
+			continue
+		}
+		if got := Counts(p); got != tc.want {
+			t.Errorf("%s: Counts = %v (heard %s), want %v", tc.name, got, p.Heard, tc.want)
+		}
+		if p.Finished != tc.finished {
+			t.Errorf("%s: Finished = %v (heard %s), want %v", tc.name, p.Finished, p.Heard, tc.finished)
+		}
+	}
+}
+
+func TestFromSong(t *testing.T) {
+	started := time.Unix(1700000000, 600_000_000)
+	l := FromSong(mpd.NowPlaying{Artist: "a", Title: "t", Album: "b", Duration: 200500 * time.Millisecond}, started)
+	want := Listen{At: time.Unix(1700000000, 0), Artist: "a", Title: "t", Album: "b", DurationMS: 200500}
+	if !l.At.Equal(want.At) || l.Artist != want.Artist || l.Title != want.Title ||
+		l.Album != want.Album || l.DurationMS != want.DurationMS {
+		t.Errorf("FromSong = %+v, want %+v", l, want)
+	}
+}
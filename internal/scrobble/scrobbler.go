@@ -0,0 +1,108 @@
+package scrobble
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Retry delays after a failed submission
+const (
+	minBackoff = 30 * time.Second
+	maxBackoff = 30 * time.Minute
+)
+
+// Scrobbler feeds one service from its spool, backing off while the
+// service is unreachable. Safe for concurrent use.
+type Scrobbler struct {
+	svc   Service
+	spool *Spool
+
+	flushing sync.Mutex // one Flush at a time, so nothing is sent twice
+
+	mu      sync.Mutex // the backoff state; never held over the network
+	backoff time.Duration
+	retryAt time.Time // zero = send right away
+}
+
+func New(svc Service, spool *Spool) *Scrobbler {
+	return &Scrobbler{svc: svc, spool: spool}
+}
+
+func (s *Scrobbler) Name() string { return s.svc.Name() }
+
+// Announces the current song. Best effort: nothing is queued, and it isn't
+// tried while backing off.
+func (s *Scrobbler) NowPlaying(ctx context.Context, l Listen) error {
+	if !s.RetryAt().IsZero() {
+		return nil
+	}
+	return s.svc.NowPlaying(ctx, l)
+}
+
+// Queues l for the next Flush.
+func (s *Scrobbler) Add(l Listen) error { return s.spool.Add(l) }
+
+// Listens waiting to be sent, oldest first.
+func (s *Scrobbler) Pending() ([]Listen, error) { return s.spool.Pending() }
+
+// When the next attempt is due after a failure; zero when not backing off.
+func (s *Scrobbler) RetryAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.retryAt
+}
+
+// Sends pending listens oldest first, dropping each batch once accepted or
+// rejected. A rejected batch is sent again one listen at a time, so only
+// the bad listens are lost. Returns how many were accepted. Does nothing
+// until the backoff runs out unless force is set.
+func (s *Scrobbler) Flush(ctx context.Context, force bool) (int, error) {
+	s.flushing.Lock()
+	defer s.flushing.Unlock()
+	if !force && time.Now().Before(s.RetryAt()) {
+		return 0, nil
+	}
+	pending, err := s.spool.Pending()
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	singles := 0 // listens left to send one by one after a rejected batch
+	var rejected error
+	for len(pending) > 0 {
+		n := min(MaxBatch, len(pending))
+		if singles > 0 {
+			n = 1
+		}
+		err := s.svc.Submit(ctx, pending[:n])
+		var rej *RejectedError
+		switch {
+		case errors.As(err, &rej) && n > 1:
+			singles = n
+			continue
+		case errors.As(err, &rej):
+			rejected = err
+		case err != nil:
+			s.mu.Lock()
+			s.backoff = min(max(2*s.backoff, minBackoff), maxBackoff)
+			s.retryAt = time.Now().Add(s.backoff)
+			s.mu.Unlock()
+			return sent, err
+		default:
+			sent += n
+		}
+		if singles > 0 {
+			singles--
+		}
+		if err := s.spool.Drop(n); err != nil {
+			return sent, err
+		}
+		pending = pending[n:]
+	}
+	s.mu.Lock()
+	s.backoff, s.retryAt = 0, time.Time{}
+	s.mu.Unlock()
+	return sent, rejected
+}
@@ -0,0 +1,182 @@
+package scrobble
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// A stand-in ListenBrainz whose answers the test can change: it refuses
+// any request holding a track called "bad", and fails with down while set.
+type fakeLB struct {
+	mu       sync.Mutex
+	down     int // status to fail with; 0 = up
+	requests int
+	accepted []string
+}
+
+func (f *fakeLB) scrobbler(t *testing.T) *Scrobbler {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			ListenType string     `json:"listen_type"`
+			Payload    []lbListen `json:"payload"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.requests++
+		if f.down != 0 {
+			w.WriteHeader(f.down)
+			return
+		}
+		if body.ListenType == "playing_now" {
+			return
+		}
+		for _, l := range body.Payload {
+			if l.Track.Title == "bad" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"bad listen"}`))
+				return
+			}
+		}
+		for _, l := range body.Payload {
+			f.accepted = append(f.accepted, l.Track.Title)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return New(&ListenBrainz{URL: srv.URL}, NewSpool(filepath.Join(t.TempDir(), "lb.jsonl")))
+}
+
+func (f *fakeLB) set(down int) {
+	f.mu.Lock()
+	f.down = down
+	f.mu.Unlock()
+}
+
+func (f *fakeLB) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests
+}
+
+func TestFlushBatches(t *testing.T) {
+	var lb fakeLB
+	s := lb.scrobbler(t)
+	var names []string
+	for i := range 2*MaxBatch + 20 {
+		names = append(names, fmt.Sprint(i))
+	}
+	for _, l := range listens(names...) {
+		if err := s.Add(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n, err := s.Flush(context.Background(), false)
+	if err != nil || n != len(names) {
+		t.Fatalf("Flush = %d, %v; want %d", n, err, len(names))
+	}
+	if lb.requests != 3 {
+		t.Errorf("%d requests, want 3 batches", lb.requests)
+	}
+	if len(lb.accepted) != len(names) || lb.accepted[0] != "0" || lb.accepted[len(names)-1] != names[len(names)-1] {
+		t.Errorf("accepted out of order or incomplete: %d listens", len(lb.accepted))
+	}
+	if p, _ := s.Pending(); len(p) != 0 {
+		t.Errorf("%d left pending", len(p))
+	}
+}
+
+func TestFlushDropsOnlyRejected(t *testing.T) {
+	var lb fakeLB
+	s := lb.scrobbler(t)
+	s.spool.Add(listens("a", "bad", "c", "d")...)
+	n, err := s.Flush(context.Background(), false)
+	var rej *RejectedError
+	if !errors.As(err, &rej) {
+		t.Fatalf("Flush error = %v, want RejectedError", err)
+	}
+	if n != 3 {
+		t.Errorf("Flush sent %d, want 3", n)
+	}
+	if got := fmt.Sprint(lb.accepted); got != "[a c d]" {
+		t.Errorf("accepted %s, want [a c d]", got)
+	}
+	if p, _ := s.Pending(); len(p) != 0 {
+		t.Errorf("%d left pending", len(p))
+	}
+	if !s.RetryAt().IsZero() {
+		t.Errorf("backing off after a rejection")
+	}
+}
+
+func TestFlushBackoff(t *testing.T) {
+	var lb fakeLB
+	lb.set(http.StatusServiceUnavailable)
+	s := lb.scrobbler(t)
+	s.spool.Add(listens("a")...)
+	ctx := context.Background()
+
+	wantRetry := func(d time.Duration) {
+		t.Helper()
+		if left := time.Until(s.RetryAt()); left > d || left < d-5*time.Second {
+			t.Errorf("retry in %v, want about %v", left, d)
+		}
+	}
+
+	if _, err := s.Flush(ctx, false); err == nil {
+		t.Fatal("Flush to a down service succeeded")
+	}
+	wantRetry(minBackoff)
+
+	// Not due yet: neither Flush nor NowPlaying touch the service.
+	before := lb.count()
+	if n, err := s.Flush(ctx, false); n != 0 || err != nil {
+		t.Errorf("Flush while backing off = %d, %v", n, err)
+	}
+	if err := s.NowPlaying(ctx, testListen); err != nil {
+		t.Errorf("NowPlaying while backing off: %v", err)
+	}
+	if lb.count() != before {
+		t.Errorf("service called while backing off")
+	}
+
+	// Forced, it tries anyway, and doubles the backoff on failure.
+	s.Flush(ctx, true)
+	wantRetry(2 * minBackoff)
+	for range 10 {
+		s.Flush(ctx, true)
+	}
+	wantRetry(maxBackoff)
+
+	lb.set(0)
+	if n, err := s.Flush(ctx, true); n != 1 || err != nil {
+		t.Fatalf("Flush after recovery = %d, %v", n, err)
+	}
+	if !s.RetryAt().IsZero() {
+		t.Errorf("still backing off after a successful flush")
+	}
+	if p, _ := s.Pending(); len(p) != 0 {
+		t.Errorf("%d left pending", len(p))
+	}
+}
+
+func TestFlushKeepsListensOnFailure(t *testing.T) {
+	var lb fakeLB
+	lb.set(http.StatusUnauthorized)
+	s := lb.scrobbler(t)
+	s.spool.Add(listens("a", "b")...)
+	if _, err := s.Flush(context.Background(), true); err == nil {
+		t.Fatal("Flush with a bad token succeeded")
+	}
+	if p, _ := s.Pending(); len(p) != 2 {
+		t.Errorf("%d pending after a failed flush, want 2", len(p))
+	}
+}
@@ -0,0 +1,112 @@
+package scrobble
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Spool is an on-disk queue of listens a service hasn't taken yet, one
+// JSON object per line, oldest first.
+type Spool struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewSpool(path string) *Spool { return &Spool{path: path} }
+
+func (s *Spool) Path() string { return s.path }
+
+// Appends ls, creating the file if needed.
+func (s *Spool) Add(ls ...Listen) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, l := range ls {
+		b, err := json.Marshal(l)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		w.Write(append(b, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Listens waiting to be sent; none when the file doesn't exist.
+func (s *Spool) Pending() ([]Listen, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read()
+}
+
+func (s *Spool) read() ([]Listen, error) {
+	f, err := os.Open(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []Listen
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var l Listen
+		if json.Unmarshal(sc.Bytes(), &l) == nil {
+			out = append(out, l)
+		}
+	}
+	return out, sc.Err()
+}
+
+// Removes the n oldest listens.
+func (s *Spool) Drop(n int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ls, err := s.read()
+	if err != nil {
+		return err
+	}
+	rest := ls[min(n, len(ls)):]
+	if len(rest) == 0 {
+		err := os.Remove(s.path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	tmp := s.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, l := range rest {
+		b, _ := json.Marshal(l)
+		w.Write(append(b, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,91 @@
+package scrobble
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func titles(ls []Listen) []string {
+	var out []string
+	for _, l := range ls {
+		out = append(out, l.Title)
+	}
+	return out
+}
+
+func listens(names ...string) []Listen {
+	out := make([]Listen, len(names))
+	for i, n := range names {
+		out[i] = testListen
+		out[i].Title = n
+	}
+	return out
+}
+
+func TestSpool(t *testing.T) {
+	sp := NewSpool(filepath.Join(t.TempDir(), "sub", "lastfm.jsonl"))
+
+	ls, err := sp.Pending()
+	if err != nil || len(ls) != 0 {
+		t.Fatalf("empty spool: Pending = %v, %v", ls, err)
+	}
+	if err := sp.Drop(1); err != nil {
+		t.Fatalf("Drop on a missing file: %v", err)
+	}
+
+	if err := sp.Add(listens("a", "b")...); err != nil {
+		t.Fatal(err)
+	}
+	if err := sp.Add(listens("c")...); err != nil {
+		t.Fatal(err)
+	}
+	ls, err = sp.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := titles(ls); len(got) != 3 || got[0] != "a" || got[2] != "c" {
+		t.Fatalf("Pending = %v, want [a b c]", got)
+	}
+	if !ls[0].At.Equal(testListen.At) || ls[0].Album != testListen.Album || ls[0].DurationMS != testListen.DurationMS {
+		t.Errorf("round trip: %+v", ls[0])
+	}
+
+	if err := sp.Drop(2); err != nil {
+		t.Fatal(err)
+	}
+	ls, _ = sp.Pending()
+	if got := titles(ls); len(got) != 1 || got[0] != "c" {
+		t.Fatalf("after Drop(2): %v, want [c]", got)
+	}
+
+	if err := sp.Drop(5); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(sp.Path()); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("empty spool file left behind: %v", err)
+	}
+}
+
+func TestSpoolSkipsBadLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lb.jsonl")
+	sp := NewSpool(path)
+	if err := sp.Add(listens("a")...); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("{not json\n")
+	f.Close()
+	if err := sp.Add(listens("b")...); err != nil {
+		t.Fatal(err)
+	}
+	ls, err := sp.Pending()
+	if got := titles(ls); err != nil || len(got) != 2 || got[1] != "b" {
+		t.Errorf("Pending = %v, %v; want [a b]", got, err)
+	}
+}
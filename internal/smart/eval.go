@@ -0,0 +1,45 @@
+package smart
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+	"github.com/AJMerr/gompc/internal/stickers"
+)
+
+// Evaluates q against the server's library. Tag rules that MPD can check
+// are sent as a filter expression; the rest is done here.
+func Evaluate(ctx context.Context, conn mpd.Conn, q *Query, now time.Time) ([]mpd.Track, error) {
+	var ts []mpd.Track
+	var err error
+	if f := q.Filter(); f != "" {
+		ts, err = conn.SearchFilter(ctx, f)
+	} else {
+		ts, err = conn.ListAll(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var db stickers.DB
+	if q.NeedsStickers() {
+		if db, err = stickers.Load(ctx, conn); err != nil {
+			return nil, fmt.Errorf("stickers: %w", err)
+		}
+	}
+	return q.Select(ts, db, now), nil
+}
+
+// Replaces the stored playlist name with ts.
+func SavePlaylist(ctx context.Context, conn mpd.Conn, name string, ts []mpd.Track) error {
+	if err := conn.PlaylistRemove(ctx, name); err != nil {
+		return err
+	}
+	for _, t := range ts {
+		if err := conn.PlaylistAdd(ctx, name, t.URI); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,471 @@
+// Package smart implements smart playlists: rules over track tags and
+// stickers, with an optional limit and sort order, evaluated against the
+// library whenever they are played.
+package smart
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+	"github.com/AJMerr/gompc/internal/stickers"
+)
+
+// A smart playlist as stored on disk (see Load).
+//
+//	match = "all"   # or "any"
+//	rules = ["genre is Jazz", "rating >= 4", "lastplayed not within 30d"]
+//	limit = "2h"    # or "50" tracks
+//	sort  = "random"
+type Playlist struct {
+	Name  string   `toml:"-" json:"name"`
+	Match string   `toml:"match,omitempty" json:"match,omitempty"`
+	Rules []string `toml:"rules" json:"rules"`
+	Limit string   `toml:"limit,omitempty" json:"limit,omitempty"`
+	Sort  string   `toml:"sort,omitempty" json:"sort,omitempty"`
+}
+
+type kind int
+
+const (
+	kindText kind = iota
+	kindNumber
+	kindDuration
+	kindTime
+	kindBool
+)
+
+type field struct {
+	kind    kind
+	tag     string // MPD tag for filter expressions; "" = client-side only
+	sticker bool
+}
+
+var fields = map[string]field{
+	"title":      {kind: kindText, tag: "title"},
+	"artist":     {kind: kindText, tag: "artist"},
+	"album":      {kind: kindText, tag: "album"},
+	"genre":      {kind: kindText, tag: "genre"},
+	"composer":   {kind: kindText, tag: "composer"},
+	"work":       {kind: kindText, tag: "work"},
+	"uri":        {kind: kindText, tag: "file"},
+	"year":       {kind: kindNumber},
+	"track":      {kind: kindNumber},
+	"disc":       {kind: kindNumber},
+	"duration":   {kind: kindDuration},
+	"rating":     {kind: kindNumber, sticker: true},
+	"playcount":  {kind: kindNumber, sticker: true},
+	"lastplayed": {kind: kindTime, sticker: true},
+	"favourite":  {kind: kindBool, sticker: true},
+}
+
+var fieldAliases = map[string]string{
+	"file": "uri", "path": "uri",
+	"date": "year", "length": "duration", "time": "duration",
+	"stars": "rating", "plays": "playcount",
+	"last_played": "lastplayed", "played": "lastplayed",
+	"favorite": "favourite", "fav": "favourite",
+}
+
+// Field names, for help and error messages.
+func Fields() []string {
+	out := make([]string, 0, len(fields))
+	for f := range fields {
+		out = append(out, f)
+	}
+	sort.Strings(out)
+	return out
+}
+
+type op int
+
+const (
+	opEq op = iota
+	opNe
+	opContains
+	opNotContains
+	opStartsWith
+	opLt
+	opLe
+	opGt
+	opGe
+	opWithin
+	opNotWithin
+)
+
+var ops = map[string]op{
+	"is": opEq, "=": opEq, "==": opEq,
+	"is not": opNe, "isnot": opNe, "is-not": opNe, "!=": opNe, "≠": opNe,
+	"contains":     opContains,
+	"not contains": opNotContains, "not-contains": opNotContains, "!contains": opNotContains,
+	"starts with": opStartsWith, "starts-with": opStartsWith,
+	"<": opLt, "<=": opLe, "≤": opLe, ">": opGt, ">=": opGe, "≥": opGe,
+	"within": opWithin, "in last": opWithin,
+	"not within": opNotWithin, "not-within": opNotWithin, "!within": opNotWithin,
+	"not in last": opNotWithin,
+}
+
+var kindOps = map[kind][]op{
+	kindText:     {opEq, opNe, opContains, opNotContains, opStartsWith},
+	kindNumber:   {opEq, opNe, opLt, opLe, opGt, opGe},
+	kindDuration: {opEq, opNe, opLt, opLe, opGt, opGe},
+	kindTime:     {opWithin, opNotWithin},
+	kindBool:     {opEq, opNe},
+}
+
+// One parsed rule: "<field> <op> <value>".
+type Rule struct {
+	Field string
+	op    op
+	text  string
+	num   int
+	dur   time.Duration
+	flag  bool
+}
+
+func ParseRule(s string) (Rule, error) {
+	words := strings.Fields(s)
+	if len(words) < 3 {
+		return Rule{}, fmt.Errorf("rule %q: want <field> <op> <value>", s)
+	}
+	name := strings.ToLower(words[0])
+	if a, ok := fieldAliases[name]; ok {
+		name = a
+	}
+	f, ok := fields[name]
+	if !ok {
+		return Rule{}, fmt.Errorf("rule %q: unknown field %q (have %s)", s, words[0], strings.Join(Fields(), ", "))
+	}
+	// longest operator first: "not in last" before "not"
+	r := Rule{Field: name}
+	n := 0
+	for k := min(3, len(words)-2); k >= 1; k-- {
+		if o, ok := ops[strings.ToLower(strings.Join(words[1:1+k], " "))]; ok {
+			r.op, n = o, k
+			break
+		}
+	}
+	if n == 0 {
+		return Rule{}, fmt.Errorf("rule %q: unknown operator %q", s, words[1])
+	}
+	allowed := false
+	for _, o := range kindOps[f.kind] {
+		allowed = allowed || o == r.op
+	}
+	if !allowed {
+		return Rule{}, fmt.Errorf("rule %q: operator %q doesn't apply to %s", s, strings.Join(words[1:1+n], " "), name)
+	}
+
+	val := strings.Trim(strings.Join(words[1+n:], " "), `"'`)
+	var err error
+	switch f.kind {
+	case kindText:
+		r.text = strings.ToLower(val)
+	case kindNumber:
+		r.num, err = strconv.Atoi(val)
+	case kindDuration:
+		r.dur, err = parseLength(val)
+	case kindTime:
+		r.dur, err = ParseSpan(val)
+	case kindBool:
+		switch strings.ToLower(val) {
+		case "true", "yes", "1":
+			r.flag = true
+		case "false", "no", "0":
+		default:
+			err = fmt.Errorf("want yes or no")
+		}
+	}
+	if err != nil {
+		return Rule{}, fmt.Errorf("rule %q: bad value %q: %v", s, val, err)
+	}
+	return r, nil
+}
+
+// Reports whether t (with stickers st) satisfies r at time now.
+func (r Rule) Match(t mpd.Track, st stickers.Song, now time.Time) bool {
+	switch fields[r.Field].kind {
+	case kindText:
+		v := strings.ToLower(textField(t, r.Field))
+		switch r.op {
+		case opEq:
+			return v == r.text
+		case opNe:
+			return v != r.text
+		case opContains:
+			return strings.Contains(v, r.text)
+		case opNotContains:
+			return !strings.Contains(v, r.text)
+		case opStartsWith:
+			return strings.HasPrefix(v, r.text)
+		}
+	case kindNumber:
+		return compare(numField(t, st, r.Field), r.num, r.op)
+	case kindDuration:
+		return compare(t.Duration, r.dur, r.op)
+	case kindTime:
+		recent := !st.LastPlayed.IsZero() && now.Sub(st.LastPlayed) <= r.dur
+		return recent == (r.op == opWithin)
+	case kindBool:
+		return (st.Favourite == r.flag) == (r.op == opEq)
+	}
+	return false
+}
+
+func compare[T int | time.Duration](a, b T, o op) bool {
+	switch o {
+	case opEq:
+		return a == b
+	case opNe:
+		return a != b
+	case opLt:
+		return a < b
+	case opLe:
+		return a <= b
+	case opGt:
+		return a > b
+	case opGe:
+		return a >= b
+	}
+	return false
+}
+
+func textField(t mpd.Track, name string) string {
+	switch name {
+	case "title":
+		return t.Title
+	case "artist":
+		return t.Artist
+	case "album":
+		return t.Album
+	case "genre":
+		return t.Genre
+	case "composer":
+		return t.Composer
+	case "work":
+		return t.Work
+	case "uri":
+		return t.URI
+	}
+	return ""
+}
+
+func numField(t mpd.Track, st stickers.Song, name string) int {
+	switch name {
+	case "year":
+		return t.Year
+	case "track":
+		return t.TrackNo
+	case "disc":
+		return t.DiscNo
+	case "rating":
+		return st.Rating
+	case "playcount":
+		return st.PlayCount
+	}
+	return 0
+}
+
+// "30d", "2w", "12h" or anything time.ParseDuration takes.
+func ParseSpan(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			if f, err := strconv.ParseFloat(n, 64); err == nil {
+				return time.Duration(f * float64(unit)), nil
+			}
+		}
+	}
+	return time.ParseDuration(s)
+}
+
+// Track lengths: "3:30", "210" (seconds) or "3m30s".
+func parseLength(s string) (time.Duration, error) {
+	if m, sec, ok := strings.Cut(s, ":"); ok {
+		mi, err1 := strconv.Atoi(m)
+		si, err2 := strconv.Atoi(sec)
+		if err1 != nil || err2 != nil {
+			return 0, fmt.Errorf("want m:ss")
+		}
+		return time.Duration(mi)*time.Minute + time.Duration(si)*time.Second, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return time.Duration(n) * time.Second, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// A compiled Playlist, ready to evaluate.
+type Query struct {
+	Source Playlist
+	any    bool
+	rules  []Rule
+
+	limitTracks int
+	limitTime   time.Duration
+
+	random   bool
+	sortBy   string
+	sortDesc bool
+}
+
+func (p Playlist) Compile() (*Query, error) {
+	q := &Query{Source: p}
+	switch strings.ToLower(p.Match) {
+	case "", "all":
+	case "any":
+		q.any = true
+	default:
+		return nil, fmt.Errorf("%s: match must be \"all\" or \"any\", not %q", p.Name, p.Match)
+	}
+	for _, s := range p.Rules {
+		r, err := ParseRule(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.Name, err)
+		}
+		q.rules = append(q.rules, r)
+	}
+	if l := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(p.Limit), "tracks")); l != "" {
+		if n, err := strconv.Atoi(l); err == nil && n > 0 {
+			q.limitTracks = n
+		} else if d, err := ParseSpan(l); err == nil && d > 0 {
+			q.limitTime = d
+		} else {
+			return nil, fmt.Errorf("%s: limit %q: want a track count or a duration like 2h", p.Name, p.Limit)
+		}
+	}
+	switch s := strings.ToLower(strings.TrimSpace(p.Sort)); s {
+	case "":
+	case "random", "shuffle":
+		q.random = true
+	default:
+		q.sortDesc = strings.HasPrefix(s, "-")
+		s = strings.TrimPrefix(s, "-")
+		if a, ok := fieldAliases[s]; ok {
+			s = a
+		}
+		if _, ok := fields[s]; !ok {
+			return nil, fmt.Errorf("%s: sort %q: want random or a field, - for descending", p.Name, p.Sort)
+		}
+		q.sortBy = s
+	}
+	return q, nil
+}
+
+// Reports whether t belongs in the playlist (before limit).
+func (q *Query) Match(t mpd.Track, st stickers.Song, now time.Time) bool {
+	if len(q.rules) == 0 {
+		return true
+	}
+	for _, r := range q.rules {
+		if r.Match(t, st, now) == q.any {
+			return q.any
+		}
+	}
+	return !q.any
+}
+
+// Whether any rule or the sort order reads stickers.
+func (q *Query) NeedsStickers() bool {
+	for _, r := range q.rules {
+		if fields[r.Field].sticker {
+			return true
+		}
+	}
+	return fields[q.sortBy].sticker
+}
+
+// An MPD filter expression narrowing the candidates server-side, or "" when
+// the rules can't be expressed that way. Results still go through Match.
+func (q *Query) Filter() string {
+	if q.any {
+		return ""
+	}
+	var parts []string
+	for _, r := range q.rules {
+		tag := fields[r.Field].tag
+		if tag == "" || (r.op != opEq && r.op != opNe) {
+			continue
+		}
+		parts = append(parts, mpd.FilterEq(tag, r.text, r.op == opNe))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(parts, " AND ") + ")"
+}
+
+// Picks the playlist's tracks out of candidates: match, sort, then limit.
+func (q *Query) Select(candidates []mpd.Track, db stickers.DB, now time.Time) []mpd.Track {
+	var out []mpd.Track
+	for _, t := range candidates {
+		if q.Match(t, db[t.URI], now) {
+			out = append(out, t)
+		}
+	}
+	switch {
+	case q.random:
+		rand.Shuffle(len(out), func(i, j int) { out[i], out[j] = out[j], out[i] })
+	case q.sortBy != "":
+		sort.SliceStable(out, func(i, j int) bool {
+			if q.sortDesc {
+				return q.less(out[j], out[i], db)
+			}
+			return q.less(out[i], out[j], db)
+		})
+	}
+	if q.limitTracks > 0 && len(out) > q.limitTracks {
+		out = out[:q.limitTracks]
+	}
+	if q.limitTime > 0 {
+		var total time.Duration
+		for i, t := range out {
+			if total+t.Duration > q.limitTime && i > 0 {
+				out = out[:i]
+				break
+			}
+			total += t.Duration
+		}
+	}
+	return out
+}
+
+func (q *Query) less(a, b mpd.Track, db stickers.DB) bool {
+	sa, sb := db[a.URI], db[b.URI]
+	switch fields[q.sortBy].kind {
+	case kindText:
+		return strings.ToLower(textField(a, q.sortBy)) < strings.ToLower(textField(b, q.sortBy))
+	case kindNumber:
+		return numField(a, sa, q.sortBy) < numField(b, sb, q.sortBy)
+	case kindDuration:
+		return a.Duration < b.Duration
+	case kindTime:
+		return sa.LastPlayed.Before(sb.LastPlayed)
+	case kindBool:
+		return !sa.Favourite && sb.Favourite
+	}
+	return false
+}
+
+// One-line summary: "genre is Jazz and rating >= 4, limit 2h, random".
+func (p Playlist) Describe() string {
+	join := " and "
+	if strings.EqualFold(p.Match, "any") {
+		join = " or "
+	}
+	parts := []string{"everything"}
+	if len(p.Rules) > 0 {
+		parts[0] = strings.Join(p.Rules, join)
+	}
+	if p.Limit != "" {
+		parts = append(parts, "limit "+p.Limit)
+	}
+	if p.Sort != "" {
+		parts = append(parts, "sort "+p.Sort)
+	}
+	return strings.Join(parts, ", ")
+}
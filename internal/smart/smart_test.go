@@ -0,0 +1,236 @@
+package smart
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+	"github.com/AJMerr/gompc/internal/stickers"
+)
+
+func TestParseRule(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want Rule
+	}{
+		{"genre is Jazz", Rule{Field: "genre", op: opEq, text: "jazz"}},
+		{"Genre IS jazz", Rule{Field: "genre", op: opEq, text: "jazz"}},
+		{"artist is not Kenny G", Rule{Field: "artist", op: opNe, text: "kenny g"}},
+		{"artist != Kenny G", Rule{Field: "artist", op: opNe, text: "kenny g"}},
+		{`album contains "Live at"`, Rule{Field: "album", op: opContains, text: "live at"}},
+		{"title not contains remix", Rule{Field: "title", op: opNotContains, text: "remix"}},
+		{"path starts with Jazz/", Rule{Field: "uri", op: opStartsWith, text: "jazz/"}},
+		{"year >= 1959", Rule{Field: "year", op: opGe, num: 1959}},
+		{"date < 2000", Rule{Field: "year", op: opLt, num: 2000}},
+		{"stars ≥ 4", Rule{Field: "rating", op: opGe, num: 4}},
+		{"plays = 0", Rule{Field: "playcount", op: opEq, num: 0}},
+		{"duration > 3:30", Rule{Field: "duration", op: opGt, dur: 210 * time.Second}},
+		{"length <= 600", Rule{Field: "duration", op: opLe, dur: 10 * time.Minute}},
+		{"time < 2m30s", Rule{Field: "duration", op: opLt, dur: 150 * time.Second}},
+		{"lastplayed within 30d", Rule{Field: "lastplayed", op: opWithin, dur: 30 * 24 * time.Hour}},
+		{"played not in last 2w", Rule{Field: "lastplayed", op: opNotWithin, dur: 14 * 24 * time.Hour}},
+		{"last_played not within 12h", Rule{Field: "lastplayed", op: opNotWithin, dur: 12 * time.Hour}},
+		{"fav is yes", Rule{Field: "favourite", op: opEq, flag: true}},
+		{"favorite != no", Rule{Field: "favourite", op: opNe}},
+	} {
+		got, err := ParseRule(tc.in)
+		if err != nil {
+			t.Errorf("ParseRule(%q): %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseRule(%q) = %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestParseRuleErrors(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"genre is", "want <field> <op> <value>"},
+		{"mood is happy", `unknown field "mood"`},
+		{"genre like Jazz", `unknown operator "like"`},
+		{"genre > Jazz", `operator ">" doesn't apply to genre`},
+		{"year contains 19", `operator "contains" doesn't apply to year`},
+		{"lastplayed is 30d", `operator "is" doesn't apply to lastplayed`},
+		{"year >= nineteen", `bad value "nineteen"`},
+		{"duration > 3:3x", `bad value "3:3x"`},
+		{"lastplayed within a while", `bad value "a while"`},
+		{"favourite is maybe", "want yes or no"},
+	} {
+		_, err := ParseRule(tc.in)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("ParseRule(%q) error = %v, want %q", tc.in, err, tc.want)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		p    Playlist
+		want string
+	}{
+		{"no rules", Playlist{}, ""},
+		{"one tag", Playlist{Rules: []string{"genre is Jazz"}}, `((genre == "jazz"))`},
+		{"negated", Playlist{Rules: []string{"artist is not Kenny G"}}, `((artist != "kenny g"))`},
+		{"uri is file", Playlist{Rules: []string{"path is a/b.flac"}}, `((file == "a/b.flac"))`},
+		{
+			"several, others left to Match",
+			Playlist{Rules: []string{"genre is Jazz", "rating >= 4", "album contains live", "composer != Bach"}},
+			`((genre == "jazz") AND (composer != "bach"))`,
+		},
+		{"quotes and backslashes", Playlist{Rules: []string{`title is say "hi" \o/`}}, `((title == "say \"hi\" \\o/"))`},
+		{"only client-side rules", Playlist{Rules: []string{"year > 1990", "title contains love"}}, ""},
+		{"any can't narrow", Playlist{Match: "any", Rules: []string{"genre is Jazz", "genre is Blues"}}, ""},
+	} {
+		q, err := tc.p.Compile()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got := q.Filter(); got != tc.want {
+			t.Errorf("%s: Filter = %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, tc := range []struct {
+		p    Playlist
+		want string
+	}{
+		{Playlist{Name: "x", Match: "some"}, `match must be "all" or "any"`},
+		{Playlist{Name: "x", Rules: []string{"genre is"}}, "x: rule"},
+		{Playlist{Name: "x", Limit: "lots"}, `limit "lots"`},
+		{Playlist{Name: "x", Limit: "0"}, `limit "0"`},
+		{Playlist{Name: "x", Sort: "-mood"}, `sort "-mood"`},
+	} {
+		_, err := tc.p.Compile()
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Compile(%+v) error = %v, want %q", tc.p, err, tc.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	kob := mpd.Track{URI: "Jazz/KoB/01.flac", Title: "So What", Artist: "Miles Davis", Genre: "Jazz", Year: 1959, Duration: 545 * time.Second}
+	st := stickers.Song{Rating: 5, PlayCount: 3, LastPlayed: now.Add(-48 * time.Hour), Favourite: true}
+	for _, tc := range []struct {
+		match string
+		rules []string
+		want  bool
+	}{
+		{"", []string{"genre is jazz", "rating >= 4"}, true},
+		{"", []string{"genre is jazz", "rating > 5"}, false},
+		{"any", []string{"genre is rock", "rating > 4"}, true},
+		{"any", []string{"genre is rock", "year < 1950"}, false},
+		{"", []string{"lastplayed within 3d"}, true},
+		{"", []string{"lastplayed not within 1d"}, true},
+		{"", []string{"lastplayed within 1d"}, false},
+		{"", []string{"uri starts with jazz/", "title contains WHAT", "duration > 9:00"}, true},
+		{"", []string{"favourite is no"}, false},
+		{"", nil, true},
+	} {
+		q, err := Playlist{Match: tc.match, Rules: tc.rules}.Compile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := q.Match(kob, st, now); got != tc.want {
+			t.Errorf("%s %v: Match = %v, want %v", tc.match, tc.rules, got, tc.want)
+		}
+	}
+	never := stickers.Song{}
+	q, _ := Playlist{Rules: []string{"lastplayed not within 30d"}}.Compile()
+	if !q.Match(kob, never, now) {
+		t.Errorf("a song never played isn't outside 30d")
+	}
+}
+
+func TestSelect(t *testing.T) {
+	now := time.Now()
+	tracks := []mpd.Track{
+		{URI: "a", Title: "Charlie", Year: 1970, Duration: 3 * time.Minute},
+		{URI: "b", Title: "alpha", Year: 1990, Duration: 5 * time.Minute},
+		{URI: "c", Title: "Bravo", Year: 1980, Duration: 4 * time.Minute},
+		{URI: "d", Title: "delta", Year: 1960, Duration: 2 * time.Minute},
+	}
+	db := stickers.DB{"a": {Rating: 2}, "b": {Rating: 5}, "c": {Rating: 4}}
+	uris := func(ts []mpd.Track) string {
+		var s []string
+		for _, t := range ts {
+			s = append(s, t.URI)
+		}
+		return strings.Join(s, "")
+	}
+	for _, tc := range []struct {
+		p    Playlist
+		want string
+	}{
+		{Playlist{}, "abcd"},
+		{Playlist{Sort: "title"}, "bcad"},
+		{Playlist{Sort: "-year"}, "bcad"},
+		{Playlist{Sort: "rating"}, "dacb"},
+		{Playlist{Sort: "-stars", Limit: "2"}, "bc"},
+		{Playlist{Limit: "3 tracks"}, "abc"},
+		{Playlist{Limit: "7m"}, "a"},                      // 3m, then 5m doesn't fit
+		{Playlist{Limit: "8m"}, "ab"},                     // exactly full
+		{Playlist{Sort: "duration", Limit: "10m"}, "dac"}, // 2m + 3m + 4m
+		{Playlist{Limit: "1m"}, "a"},                      // the first song always goes in
+		{Playlist{Rules: []string{"year >= 1980"}, Sort: "year"}, "cb"},
+		{Playlist{Rules: []string{"year > 2000"}, Limit: "1h"}, ""},
+	} {
+		q, err := tc.p.Compile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := uris(q.Select(tracks, db, now)); got != tc.want {
+			t.Errorf("%+v: Select = %q, want %q", tc.p, got, tc.want)
+		}
+	}
+
+	q, _ := Playlist{Sort: "random"}.Compile()
+	got := []byte(uris(q.Select(tracks, db, now)))
+	seen := map[byte]bool{}
+	for _, c := range got {
+		seen[c] = true
+	}
+	if len(got) != 4 || len(seen) != 4 {
+		t.Errorf("random Select = %q, want a shuffle of abcd", got)
+	}
+}
+
+func TestParseSpan(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"30d":  30 * 24 * time.Hour,
+		"2w":   14 * 24 * time.Hour,
+		"1.5d": 36 * time.Hour,
+		"12h":  12 * time.Hour,
+		" 90m": 90 * time.Minute,
+	} {
+		if got, err := ParseSpan(in); err != nil || got != want {
+			t.Errorf("ParseSpan(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "d", "soon", "3x"} {
+		if _, err := ParseSpan(in); err == nil {
+			t.Errorf("ParseSpan(%q) succeeded", in)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	for _, tc := range []struct {
+		p    Playlist
+		want string
+	}{
+		{Playlist{}, "everything"},
+		{Playlist{Rules: []string{"genre is Jazz", "rating >= 4"}, Limit: "2h", Sort: "random"}, "genre is Jazz and rating >= 4, limit 2h, sort random"},
+		{Playlist{Match: "ANY", Rules: []string{"genre is Jazz", "genre is Blues"}}, "genre is Jazz or genre is Blues"},
+	} {
+		if got := tc.p.Describe(); got != tc.want {
+			t.Errorf("Describe(%s) = %q, want %q", fmt.Sprint(tc.p.Rules), got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package smart
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Smart playlists live next to config.toml, one file each:
+// <config dir>/smart/<name>.toml.
+func Dir(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), "smart")
+}
+
+// Reads every playlist in dir, sorted by name. A missing dir has none.
+func LoadAll(dir string) ([]Playlist, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.toml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	out := make([]Playlist, 0, len(paths))
+	for _, path := range paths {
+		p, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func Load(dir, name string) (Playlist, error) {
+	if err := validName(name); err != nil {
+		return Playlist{}, err
+	}
+	p, err := readFile(filepath.Join(dir, name+".toml"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Playlist{}, fmt.Errorf("no smart playlist %q in %s", name, dir)
+	}
+	return p, err
+}
+
+func readFile(path string) (Playlist, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Playlist{}, err
+	}
+	var p Playlist
+	if err := toml.Unmarshal(b, &p); err != nil {
+		return Playlist{}, fmt.Errorf("%s: %w", path, err)
+	}
+	p.Name = strings.TrimSuffix(filepath.Base(path), ".toml")
+	return p, nil
+}
+
+// Writes p to dir, replacing any playlist of the same name. p must compile.
+func Save(dir string, p Playlist) error {
+	if err := validName(p.Name); err != nil {
+		return err
+	}
+	if _, err := p.Compile(); err != nil {
+		return err
+	}
+	b, err := toml.Marshal(p)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, p.Name+".toml"), b, 0o644)
+}
+
+func Remove(dir, name string) error {
+	if err := validName(name); err != nil {
+		return err
+	}
+	err := os.Remove(filepath.Join(dir, name+".toml"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("no smart playlist %q in %s", name, dir)
+	}
+	return err
+}
+
+func validName(name string) error {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("bad smart playlist name %q", name)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Package stickers keeps gompc's per-song data in MPD's sticker database:
+// ratings, favourites, play counts and when a song was last played.
+package stickers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/AJMerr/gompc/internal/mpd"
+)
+
+// Sticker names. Other clients use "rating" too; the rest are ours.
+const (
+	Rating     = "rating"     // 0-5 stars
+	Favourite  = "favourite"  // "1" when set
+	PlayCount  = "playcount"  // finished plays
+	LastPlayed = "lastplayed" // unix seconds
+)
+
+var Names = []string{Rating, Favourite, PlayCount, LastPlayed}
+
+// Sticker data of one song.
+type Song struct {
+	Rating     int
+	Favourite  bool
+	PlayCount  int
+	LastPlayed time.Time
+}
+
+// Sticker data by song uri.
+type DB map[string]Song
+
+// One sticker change; Value "" means deleted.
+type Change struct {
+	URI, Name, Value string
+}
+
+// Reads the gompc stickers of the whole library.
+func Load(ctx context.Context, conn mpd.Conn) (DB, error) {
+	db := DB{}
+	for _, name := range Names {
+		found, err := conn.StickerFind(ctx, "", name)
+		if err != nil {
+			return nil, err
+		}
+		for uri, v := range found {
+			db.Apply(Change{URI: uri, Name: name, Value: v})
+		}
+	}
+	return db, nil
+}
+
+// Updates db with c, dropping songs left with no stickers.
+func (db DB) Apply(c Change) {
+	s := db[c.URI]
+	n, _ := strconv.Atoi(c.Value)
+	switch c.Name {
+	case Rating:
+		s.Rating = min(max(n, 0), 5)
+	case Favourite:
+		s.Favourite = c.Value != "" && c.Value != "0"
+	case PlayCount:
+		s.PlayCount = max(n, 0)
+	case LastPlayed:
+		s.LastPlayed = time.Time{}
+		if n > 0 {
+			s.LastPlayed = time.Unix(int64(n), 0)
+		}
+	default:
+		return
+	}
+	if s == (Song{}) {
+		delete(db, c.URI)
+		return
+	}
+	db[c.URI] = s
+}
+
+// Sets uri's rating; 0 stars removes it.
+func SetRating(ctx context.Context, conn mpd.Conn, uri string, stars int) (Change, error) {
+	c := Change{URI: uri, Name: Rating}
+	if stars <= 0 {
+		return c, conn.StickerDelete(ctx, uri, Rating)
+	}
+	c.Value = strconv.Itoa(min(stars, 5))
+	return c, conn.StickerSet(ctx, uri, Rating, c.Value)
+}
+
+func SetFavourite(ctx context.Context, conn mpd.Conn, uri string, on bool) (Change, error) {
+	c := Change{URI: uri, Name: Favourite}
+	if !on {
+		return c, conn.StickerDelete(ctx, uri, Favourite)
+	}
+	c.Value = "1"
+	return c, conn.StickerSet(ctx, uri, Favourite, c.Value)
+}
+
+// Bumps uri's play count and sets its last-played time to at. The count is
+// read back from the server so several clients don't lose plays.
+func RecordPlay(ctx context.Context, conn mpd.Conn, uri string, at time.Time) ([]Change, error) {
+	n := 0
+	v, err := conn.StickerGet(ctx, uri, PlayCount)
+	switch {
+	case err == nil:
+		n, _ = strconv.Atoi(v)
+	case !errors.Is(err, mpd.ErrNoSticker):
+		return nil, err
+	}
+	out := []Change{
+		{URI: uri, Name: PlayCount, Value: strconv.Itoa(n + 1)},
+		{URI: uri, Name: LastPlayed, Value: strconv.FormatInt(at.Unix(), 10)},
+	}
+	for _, c := range out {
+		if err := conn.StickerSet(ctx, c.URI, c.Name, c.Value); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}